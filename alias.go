@@ -0,0 +1,101 @@
+package x86db
+
+// Alias is a pseudo-mnemonic standing for another instruction with an implied
+// imm8 operand, eg. CMPEQPS is CMPPS with an imm8 of 0.
+type Alias struct {
+	Name      string
+	Canonical string
+	Imm8      byte
+}
+
+type aliasPredicate struct {
+	name string
+	imm8 byte
+}
+
+// aliasFamily describes a family of pseudo-mnemonics. Aliases are named
+// prefix + predicate + suffix and stand for prefix + infix + suffix.
+type aliasFamily struct {
+	prefix     string
+	infix      string
+	suffixes   []string
+	predicates []aliasPredicate
+}
+
+// sseComparePredicates are the predicates of the SSE CMPPS family.
+var sseComparePredicates = []aliasPredicate{
+	{"EQ", 0x00}, {"LT", 0x01}, {"LE", 0x02}, {"UNORD", 0x03},
+	{"NEQ", 0x04}, {"NLT", 0x05}, {"NLE", 0x06}, {"ORD", 0x07},
+}
+
+// avxComparePredicates are the predicates of the AVX VCMPPS family. They
+// extend the SSE ones with the signaling/quiet and ordered/unordered
+// variants.
+var avxComparePredicates = append(sseComparePredicates, []aliasPredicate{
+	{"EQ_UQ", 0x08}, {"NGE", 0x09}, {"NGT", 0x0a}, {"FALSE", 0x0b},
+	{"NEQ_OQ", 0x0c}, {"GE", 0x0d}, {"GT", 0x0e}, {"TRUE", 0x0f},
+	{"EQ_OS", 0x10}, {"LT_OQ", 0x11}, {"LE_OQ", 0x12}, {"UNORD_S", 0x13},
+	{"NEQ_US", 0x14}, {"NLT_UQ", 0x15}, {"NLE_UQ", 0x16}, {"ORD_S", 0x17},
+	{"EQ_US", 0x18}, {"NGE_UQ", 0x19}, {"NGT_UQ", 0x1a}, {"FALSE_OS", 0x1b},
+	{"NEQ_OS", 0x1c}, {"GE_OQ", 0x1d}, {"GT_OQ", 0x1e}, {"TRUE_US", 0x1f},
+
+	// Explicit names of the predicates above
+	{"EQ_OQ", 0x00}, {"LT_OS", 0x01}, {"LE_OS", 0x02}, {"UNORD_Q", 0x03},
+	{"NEQ_UQ", 0x04}, {"NLT_US", 0x05}, {"NLE_US", 0x06}, {"ORD_Q", 0x07},
+	{"NGE_US", 0x09}, {"NGT_US", 0x0a}, {"FALSE_OQ", 0x0b}, {"GE_OS", 0x0d},
+	{"GT_OS", 0x0e}, {"TRUE_UQ", 0x0f},
+}...)
+
+// xopComparePredicates are the predicates of the XOP VPCOMB family.
+var xopComparePredicates = []aliasPredicate{
+	{"LT", 0x00}, {"LE", 0x01}, {"GT", 0x02}, {"GE", 0x03},
+	{"EQ", 0x04}, {"NEQ", 0x05}, {"FALSE", 0x06}, {"TRUE", 0x07},
+}
+
+// clmulPredicates select the quadwords multiplied by PCLMULQDQ.
+var clmulPredicates = []aliasPredicate{
+	{"LQLQ", 0x00}, {"HQLQ", 0x01}, {"LQHQ", 0x10}, {"HQHQ", 0x11},
+}
+
+var aliasFamilies = []aliasFamily{
+	{"CMP", "", []string{"PS", "PD", "SS", "SD"}, sseComparePredicates},
+	{"VCMP", "", []string{"PS", "PD", "SS", "SD"}, avxComparePredicates},
+	{"VPCOM", "", []string{"B", "W", "D", "Q", "UB", "UW", "UD", "UQ"},
+		xopComparePredicates},
+	{"PCLMUL", "Q", []string{"DQ"}, clmulPredicates},
+	{"VPCLMUL", "Q", []string{"DQ"}, clmulPredicates},
+}
+
+// buildAliases indexes the pseudo-mnemonics of the instructions in the DB.
+func (db *DB) buildAliases() {
+	known := make(map[string]bool)
+	for _, insn := range db.Instructions {
+		known[insn.Name] = true
+	}
+
+	db.aliases = make(map[string]Alias)
+	for _, family := range aliasFamilies {
+		for _, suffix := range family.suffixes {
+			canonical := family.prefix + family.infix + suffix
+			if !known[canonical] {
+				continue
+			}
+
+			for _, p := range family.predicates {
+				name := family.prefix + p.name + suffix
+				db.aliases[name] = Alias{name, canonical, p.imm8}
+			}
+		}
+	}
+}
+
+// Canonical returns the instruction a pseudo-mnemonic stands for and the
+// value of its implied imm8 operand, eg. "CMPPS" and 0 for "CMPEQPS". ok is
+// false if name isn't a pseudo-mnemonic.
+func (db *DB) Canonical(name string) (canonical string, imm8 byte, ok bool) {
+	alias, ok := db.aliases[name]
+	if !ok {
+		return name, 0, false
+	}
+	return alias.Canonical, alias.Imm8, true
+}
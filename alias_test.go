@@ -0,0 +1,49 @@
+package x86db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonical(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+
+	tests := []struct {
+		name      string
+		ok        bool
+		canonical string
+		imm8      byte
+	}{
+		{"CMPEQPS", true, "CMPPS", 0x00},
+		{"CMPNLESD", true, "CMPSD", 0x06},
+		{"VCMPTRUE_USSD", true, "VCMPSD", 0x1f},
+		{"VCMPEQ_OQPD", true, "VCMPPD", 0x00},
+		{"VPCOMNEQUW", true, "VPCOMUW", 0x05},
+		{"PCLMULHQLQDQ", true, "PCLMULQDQ", 0x01},
+		{"VPCLMULLQHQDQ", true, "VPCLMULQDQ", 0x10},
+		{"CMPPS", false, "CMPPS", 0},
+		{"CMPSD", false, "CMPSD", 0},
+		{"VPCMPEQB", false, "VPCMPEQB", 0},
+	}
+
+	for _, test := range tests {
+		canonical, imm8, ok := db.Canonical(test.name)
+		assert.Equal(t, test.ok, ok, test.name)
+		assert.Equal(t, test.canonical, canonical, test.name)
+		assert.Equal(t, test.imm8, imm8, test.name)
+	}
+
+	// The implied imm8 is the last byte of the pseudo-mnemonics encoding.
+	for _, insn := range db.Instructions {
+		_, imm8, ok := db.Canonical(insn.Name)
+		if !ok {
+			continue
+		}
+		suffix := insn.Encoding.Suffix
+		if assert.NotEmpty(t, suffix, insn.Name) {
+			assert.Equal(t, imm8, suffix[len(suffix)-1], insn.Name)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"text/tabwriter"
 
 	"github.com/dlespiau/x86db"
+	"github.com/dlespiau/x86db/predicate"
 )
 
 var nasmToPlan9 = map[string]string{
@@ -16,63 +17,14 @@ var nasmToPlan9 = map[string]string{
 	// SSE
 	//
 
-	// The condition is encoded as an imm8 operand of CMPPS
-	"CMPEQPS":    "CMPPS",
-	"CMPLTPS":    "CMPPS",
-	"CMPLEPS":    "CMPPS",
-	"CMPUNORDPS": "CMPPS",
-	"CMPNEQPS":   "CMPPS",
-	"CMPNLTPS":   "CMPPS",
-	"CMPNLEPS":   "CMPPS",
-	"CMPORDPS":   "CMPPS",
-
-	// The condition is encoded as an imm8 operand of CMPSS.
-	"CMPEQSS":    "CMPSS",
-	"CMPLTSS":    "CMPSS",
-	"CMPLESS":    "CMPSS",
-	"CMPUNORDSS": "CMPSS",
-	"CMPNEQSS":   "CMPSS",
-	"CMPNLTSS":   "CMPSS",
-	"CMPNLESS":   "CMPSS",
-	"CMPORDSS":   "CMPSS",
-
 	"CVTSI2SS":  "CVTSL2SS",
 	"CVTSS2SI":  "CVTSS2SL",
 	"CVTTSS2SI": "CVTTSS2SL",
 
-	//
-	// PCLMULQDQ
-	//
-
-	"PCLMULLQLQDQ": "PCLMULQDQ",
-	"PCLMULHQLQDQ": "PCLMULQDQ",
-	"PCLMULLQHQDQ": "PCLMULQDQ",
-	"PCLMULHQHQDQ": "PCLMULQDQ",
-
 	//
 	// SSE2
 	//
 
-	// The condition is encoded as an imm8 operand of CMPPD
-	"CMPEQPD":    "CMPPD",
-	"CMPLTPD":    "CMPPD",
-	"CMPLEPD":    "CMPPD",
-	"CMPUNORDPD": "CMPPD",
-	"CMPNEQPD":   "CMPPD",
-	"CMPNLTPD":   "CMPPD",
-	"CMPNLEPD":   "CMPPD",
-	"CMPORDPD":   "CMPPD",
-
-	// The condition is encoded as an imm8 operand of CMPSD.
-	"CMPEQSD":    "CMPSD",
-	"CMPLTSD":    "CMPSD",
-	"CMPLESD":    "CMPSD",
-	"CMPUNORDSD": "CMPSD",
-	"CMPNEQSD":   "CMPSD",
-	"CMPNLTSD":   "CMPSD",
-	"CMPNLESD":   "CMPSD",
-	"CMPORDSD":   "CMPSD",
-
 	// D (double word) has been replaced by L (Long)
 	// DQ (double quadword) has been replaced by O (Octoword)
 	"MASKMOVDQU": "MASKMOVOU",
@@ -110,7 +62,11 @@ var nasmToPlan9 = map[string]string{
 	"CVTTSD2SI": "CVTTSD2SL",
 }
 
-func nasmOpcodeToPlan9(op string) string {
+// nasmOpcodeToPlan9 returns the go assembler name of a nasm instruction.
+// Pseudo-mnemonics with an implied imm8, eg. CMPEQPS, are first translated to
+// their canonical instruction.
+func nasmOpcodeToPlan9(db *x86db.DB, op string) string {
+	op, _, _ = db.Canonical(op)
 	plan9, ok := nasmToPlan9[op]
 	if ok {
 		return plan9
@@ -118,36 +74,31 @@ func nasmOpcodeToPlan9(op string) string {
 	return op
 }
 
-func isAlreadyKnown(insn *x86db.Instruction) bool {
-	name := nasmOpcodeToPlan9(insn.Name)
+// knownNames indexes Anames.
+var knownNames = make(map[string]bool)
+
+func init() {
 	for _, opcode := range Anames {
-		if name == opcode {
-			return true
-		}
+		knownNames[opcode] = true
 	}
-	return false
-}
-
-func isAlreadyTested(insn *x86db.Instruction) bool {
-	name := nasmOpcodeToPlan9(insn.Name)
-	_, ok := testedMap[name]
-	return ok
 }
 
-func isMMXOperand(op string) bool {
-	if op == "mmxreg" || op == "mmxrm" || op == "mmxrm64" {
-		return true
+// isAlreadyKnown selects the instructions known by the go assembler.
+func isAlreadyKnown(db *x86db.DB) predicate.Predicate {
+	return func(insn x86db.Instruction) bool {
+		name := nasmOpcodeToPlan9(db, insn.Name)
+		return knownNames[name]
 	}
-	return false
 }
 
-func isMMX(insn *x86db.Instruction) bool {
-	for _, op := range insn.Operands {
-		if isMMXOperand(op) {
-			return true
-		}
+// isAlreadyTested selects the instructions with test cases in the go
+// assembler.
+func isAlreadyTested(db *x86db.DB) predicate.Predicate {
+	return func(insn x86db.Instruction) bool {
+		name := nasmOpcodeToPlan9(db, insn.Name)
+		_, ok := testedMap[name]
+		return ok
 	}
-	return false
 }
 
 func doList(insns x86db.InstructionSlice) {
@@ -163,7 +114,13 @@ func doList(insns x86db.InstructionSlice) {
 var (
 	filterFlags = flag.NewFlagSet("filter", flag.ExitOnError)
 	extension   = filterFlags.String("extension", "",
-		"select instructions by extension")
+		"select instructions by extension (comma separated: all required)")
+	group = filterFlags.String("group", "",
+		"select instructions by group (title prefix or number)")
+	mode = filterFlags.String("mode", "",
+		"select instructions valid in the execution mode (16, 32 or 64)")
+	query = filterFlags.String("query", "",
+		"select instructions matching the query, eg. 'ext:SSE2 and not class:mmx'")
 	notMMX = filterFlags.Bool("not-mmx", false,
 		"do not select instructions taking MMX operands")
 	known = filterFlags.Bool("known", false,
@@ -224,7 +181,7 @@ func main() {
 	}
 	defer db.Close()
 
-	insns := db.Instructions
+	var filter []predicate.Predicate
 
 	if *extension != "" {
 		if *extension == "help" {
@@ -235,41 +192,72 @@ func main() {
 			w.Flush()
 			os.Exit(0)
 		}
-		ext, err := x86db.ExtensionFromString(*extension)
+		exts, err := x86db.ExtensionSetFromString(*extension)
 		if err != nil {
 			log.Fatal(err)
 		}
-		insns = insns.Where(func(insn x86db.Instruction) bool {
-			return insn.Extension == ext
-		})
+		filter = append(filter, predicate.Extensions(exts))
 	}
 
-	if *notMMX {
-		insns = insns.Where(func(insn x86db.Instruction) bool {
-			return !isMMX(&insn)
-		})
+	if *group != "" {
+		if *group == "help" {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+			for _, g := range db.Groups() {
+				fmt.Fprintf(w, "  %d\t%s\n", g.Ordinal, g.Title)
+			}
+			w.Flush()
+			os.Exit(0)
+		}
+		g, err := db.GroupFromString(*group)
+		if err != nil {
+			log.Fatal(err)
+		}
+		filter = append(filter, predicate.Group(g))
 	}
 
-	if *known || *notKnown {
-		insns = insns.Where(func(insn x86db.Instruction) bool {
-			k := isAlreadyKnown(&insn)
-			if *notKnown {
-				return !k
-			}
-			return k
-		})
+	if *mode != "" {
+		modes, err := x86db.ModesFromString(*mode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		filter = append(filter, predicate.Mode(modes))
 	}
 
-	if *tested || *notTested {
-		insns = insns.Where(func(insn x86db.Instruction) bool {
-			t := isAlreadyTested(&insn)
-			if *notTested {
-				return !t
+	if *query != "" {
+		if *query == "help" {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+			for _, field := range predicate.QueryFields() {
+				fmt.Fprintf(w, "  %s\t%s\n", field.Name, field.Help)
 			}
-			return t
-		})
+			w.Flush()
+			os.Exit(0)
+		}
+		p, err := predicate.Parse(*query)
+		if err != nil {
+			log.Fatal(err)
+		}
+		filter = append(filter, p)
 	}
 
+	if *notMMX {
+		filter = append(filter,
+			predicate.Not(predicate.OperandClass(x86db.RegisterClassMMX)))
+	}
+
+	if *notKnown {
+		filter = append(filter, predicate.Not(isAlreadyKnown(db)))
+	} else if *known {
+		filter = append(filter, isAlreadyKnown(db))
+	}
+
+	if *notTested {
+		filter = append(filter, predicate.Not(isAlreadyTested(db)))
+	} else if *tested {
+		filter = append(filter, isAlreadyTested(db))
+	}
+
+	insns := db.Instructions.Where(predicate.And(filter...))
+
 	cmdName := os.Args[1]
 	handled := false
 	for _, cmd := range commands {
@@ -0,0 +1,486 @@
+// x86db-ildgen generates the tables of the ild package from the instruction
+// patterns of the DB.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+
+	"github.com/dlespiau/x86db"
+)
+
+// The operand size states of an instruction, see states.
+const (
+	stateNone = iota
+	state66
+	stateW
+	numStates
+)
+
+const (
+	flagModRM = 1 << iota
+	flagMoffs
+	flagModRMReg
+)
+
+// A table entry holds the number of immediate bytes of each state in a nibble
+// followed by the flags, entryValid being set for valid opcodes. The entries
+// with a select bit set are the offset of a row of selectors instead.
+const (
+	flagsShift   = 12
+	entryValid   = 1 << 15
+	selectDigit  = 1 << 30
+	selectPrefix = 1 << 31
+)
+
+var modes = []struct {
+	mode  x86db.Modes
+	bits  int
+	index int
+}{
+	{x86db.Mode16, 16, 0},
+	{x86db.Mode32, 32, 1},
+	{x86db.Mode64, 64, 2},
+}
+
+// info describes the bytes following the opcode byte.
+type info struct {
+	flags uint8
+	imm   [numStates]uint8
+}
+
+// slot is an info being built, rank is the priority of the form it comes
+// from.
+type slot struct {
+	flags [numStates]uint8
+	imm   [numStates]int
+	set   [numStates]bool
+	rank  [numStates]int
+	line  [numStates]int
+	// conflict is the line of a form of the same rank giving a different
+	// length.
+	conflict [numStates]int
+}
+
+type key struct {
+	mode   int
+	space  x86db.EncodingSpace
+	m      x86db.OpcodeMap
+	opcode byte
+}
+
+// grid holds the slots of an opcode by mandatory prefix and ModRM.reg.
+type grid [4][8]slot
+
+var prefixIndex = map[byte]int{0: 0, 0x66: 1, 0xf3: 2, 0xf2: 3}
+
+// states returns the operand size states a form with the size marker applies to
+// in a bits-bit mode and the operand size of each state.
+func states(bits int, size x86db.SizeMarker) ([]int, [numStates]int) {
+	var opsize [numStates]int
+	switch bits {
+	case 16:
+		opsize = [numStates]int{16, 32, 32}
+	case 32:
+		opsize = [numStates]int{32, 16, 32}
+	default:
+		opsize = [numStates]int{32, 16, 64}
+	}
+
+	switch size {
+	case x86db.Size16:
+		if bits == 16 {
+			return []int{stateNone}, opsize
+		}
+		return []int{state66}, opsize
+	case x86db.Size32:
+		if bits == 16 {
+			return []int{state66}, opsize
+		}
+		return []int{stateNone}, opsize
+	case x86db.Size64:
+		return []int{stateW}, opsize
+	case x86db.Size64NoW, x86db.SizeDefault:
+		if bits == 64 {
+			return []int{stateNone, stateW}, opsize
+		}
+		return []int{stateNone}, opsize
+	}
+	return []int{stateNone, state66, stateW}, opsize
+}
+
+// rank orders the forms giving the length of the same bytes: ND forms are
+// alternate encodings and the vendor specific forms lose against the others.
+// Then forms with a mandatory prefix or a ModRM.reg value are more specific.
+func rank(insn *x86db.Instruction, prefix, digit bool) int {
+	r := 0
+	if !insn.Attr.Has(x86db.AttrND) {
+		r += 8
+	}
+	if !insn.Attr.Has(x86db.AttrCYRIX) && !insn.Attr.Has(x86db.AttrUNDOC) {
+		r += 4
+	}
+	if prefix {
+		r += 2
+	}
+	if digit {
+		r++
+	}
+	return r
+}
+
+func addForm(grids map[key]*grid, bits, mode int, insn *x86db.Instruction) {
+	e := &insn.Encoding
+
+	// A second opcode byte is a ModRM byte with mod == 3, eg. "0f 01 f8",
+	// except for the base of AAD and AAM, "d5 0a", which is an immediate.
+	flags := uint8(0)
+	var digits []int
+	extra := 0
+	switch {
+	case len(e.Opcode) > 1 && e.Opcode[1] < 0xc0:
+		extra = len(e.Opcode) - 1
+	case len(e.Opcode) > 1:
+		flags |= flagModRM
+		digits = []int{int(e.Opcode[1] >> 3 & 7)}
+	case e.ModRM != nil:
+		flags |= flagModRM
+		if e.ModRM.Digit >= 0 {
+			digits = []int{e.ModRM.Digit}
+		}
+	}
+	// MOV to and from control, debug and test registers ignore ModRM.mod.
+	for _, arg := range insn.Args {
+		switch arg.Class {
+		case x86db.RegisterClassControl, x86db.RegisterClassDebug,
+			x86db.RegisterClassTest:
+			flags |= flagModRMReg
+		}
+	}
+	explicitDigit := digits != nil
+	if digits == nil {
+		digits = []int{0, 1, 2, 3, 4, 5, 6, 7}
+	}
+
+	prefix := e.Prefix
+	switch {
+	case e.Flags.Has(x86db.EncodingMustRep):
+		prefix = 0xf3
+	case e.Flags.Has(x86db.EncodingMustRepNE):
+		prefix = 0xf2
+	}
+	var prefixes []int
+	explicitPrefix := prefix != 0 || e.Flags.Has(x86db.EncodingNP) ||
+		e.Space != x86db.EncodingLegacy
+	if explicitPrefix {
+		prefixes = []int{prefixIndex[prefix]}
+	} else {
+		prefixes = []int{0, 1, 2, 3}
+	}
+
+	stateList, opsize := states(bits, e.OperandSize)
+	if e.Space != x86db.EncodingLegacy {
+		stateList = []int{stateNone, state66, stateW}
+	}
+	var imm [numStates]int
+	for _, s := range stateList {
+		imm[s] = extra + len(e.Suffix)
+		for _, i := range e.Immediates {
+			if i.Type == x86db.ImmWordDwordQword {
+				flags |= flagMoffs
+			}
+			// The moffs length is left to the runtime, see flagMoffs.
+			imm[s] += i.Type.Length(opsize[s], 0)
+		}
+	}
+
+	r := rank(insn, explicitPrefix, explicitDigit)
+	opcodes := []byte{e.Opcode[0]}
+	if e.Add == x86db.AddRegister && len(e.Opcode) == 1 {
+		for n := byte(1); n < 8; n++ {
+			opcodes = append(opcodes, e.Opcode[0]+n)
+		}
+	}
+
+	for _, opcode := range opcodes {
+		k := key{mode, e.Space, e.Map, opcode}
+		g := grids[k]
+		if g == nil {
+			g = &grid{}
+			grids[k] = g
+		}
+		for _, p := range prefixes {
+			for _, d := range digits {
+				sl := &g[p][d]
+				for _, s := range stateList {
+					if sl.set[s] && sl.rank[s] > r {
+						continue
+					}
+					if sl.set[s] && sl.rank[s] == r {
+						if sl.imm[s] != imm[s] || sl.flags[s] != flags {
+							sl.conflict[s] = insn.Line
+						}
+						continue
+					}
+					sl.flags[s] = flags
+					sl.imm[s] = imm[s]
+					sl.set[s] = true
+					sl.rank[s] = r
+					sl.line[s] = insn.Line
+					sl.conflict[s] = 0
+				}
+			}
+		}
+	}
+}
+
+// resolve returns the info of a slot, filling the states no form applies to.
+func (sl *slot) resolve() (info, bool) {
+	if !sl.set[stateNone] && !sl.set[state66] && !sl.set[stateW] {
+		return info{}, false
+	}
+	imm := sl.imm
+	switch {
+	case sl.set[stateNone]:
+	case sl.set[stateW]:
+		imm[stateNone] = sl.imm[stateW]
+	default:
+		imm[stateNone] = sl.imm[state66]
+	}
+	if !sl.set[state66] {
+		imm[state66] = imm[stateNone]
+	}
+	if !sl.set[stateW] {
+		imm[stateW] = imm[stateNone]
+	}
+
+	for s, line := range sl.conflict {
+		if line != 0 {
+			conflicts[[2]int{sl.line[s], line}] = true
+		}
+	}
+
+	// The flags are those of the highest ranked form.
+	in := info{}
+	best := -1
+	for s := range sl.flags {
+		if sl.set[s] && sl.rank[s] > best {
+			in.flags = sl.flags[s]
+			best = sl.rank[s]
+		}
+	}
+	for s := range imm {
+		in.imm[s] = uint8(imm[s])
+	}
+	return in, true
+}
+
+// conflicts holds the pairs of lines of the forms giving different lengths to
+// the same bytes.
+var conflicts = make(map[[2]int]bool)
+
+type generator struct {
+	// selectors holds the rows of entries selected by the mandatory prefix
+	// and by ModRM.reg.
+	selectors      []uint32
+	prefixSelector map[[4]uint32]uint32
+	digitSelector  map[[8]uint32]uint32
+	tables         [][256]uint32
+}
+
+// entry returns the table entry of in.
+func entry(in info) uint32 {
+	e := entryValid | uint32(in.flags)<<flagsShift
+	for s, n := range in.imm {
+		if n > 15 {
+			log.Fatalf("%d immediate bytes", n)
+		}
+		e |= uint32(n) << uint(4*s)
+	}
+	return e
+}
+
+// digitEntry returns the entry selecting one of row by ModRM.reg.
+func (g *generator) digitEntry(row [8]uint32) uint32 {
+	uniform := true
+	for _, e := range row {
+		uniform = uniform && e == row[0]
+	}
+	if uniform {
+		return row[0]
+	}
+	if n, ok := g.digitSelector[row]; ok {
+		return n
+	}
+	n := selectDigit | uint32(len(g.selectors))
+	g.selectors = append(g.selectors, row[:]...)
+	g.digitSelector[row] = n
+	return n
+}
+
+// opcodeEntry returns the table entry of an opcode.
+func (g *generator) opcodeEntry(gr *grid, legacy bool) uint32 {
+	var rows [4]uint32
+	for p := range gr {
+		var row [8]uint32
+		for d := range gr[p] {
+			in, ok := gr[p][d].resolve()
+			if !ok && legacy && p != 0 {
+				// Prefixes not used as mandatory prefix are operand
+				// size or repeat prefixes.
+				in, ok = gr[0][d].resolve()
+			}
+			if ok {
+				row[d] = entry(in)
+			}
+		}
+		rows[p] = g.digitEntry(row)
+	}
+
+	if rows[1] == rows[0] && rows[2] == rows[0] && rows[3] == rows[0] {
+		return rows[0]
+	}
+	if n, ok := g.prefixSelector[rows]; ok {
+		return n
+	}
+	n := selectPrefix | uint32(len(g.selectors))
+	g.selectors = append(g.selectors, rows[:]...)
+	g.prefixSelector[rows] = n
+	return n
+}
+
+// table returns the index of the table t, shared with identical tables.
+func (g *generator) table(t [256]uint32) int {
+	for n := range g.tables {
+		if g.tables[n] == t {
+			return n
+		}
+	}
+	g.tables = append(g.tables, t)
+	return len(g.tables) - 1
+}
+
+const numMaps = 11
+
+var spaceNames = []string{"spaceLegacy", "spaceVEX", "spaceXOP", "spaceEVEX"}
+
+func main() {
+	output := flag.String("o", "tables.go", "output file")
+	flag.Parse()
+
+	db := x86db.NewDBWithOptions("", x86db.LoadOptions{ExpandConditions: true})
+	if err := db.Open(); err != nil {
+		log.Fatal(err)
+	}
+
+	grids := make(map[key]*grid)
+	for i := range db.Instructions {
+		insn := &db.Instructions[i]
+		// The wait prefix is the WAIT instruction.
+		if insn.Encoding.Flags.Has(x86db.EncodingWait) {
+			if len(insn.Encoding.Opcode) != 0 {
+				continue
+			}
+			wait := *insn
+			wait.Encoding.Opcode = []byte{0x9b}
+			insn = &wait
+		}
+		if len(insn.Encoding.Opcode) == 0 || insn.Encoding.Add == x86db.AddCondition {
+			continue
+		}
+		for _, m := range modes {
+			if insn.Modes.Has(m.mode) {
+				addForm(grids, m.bits, m.index, insn)
+			}
+		}
+	}
+
+	g := &generator{
+		prefixSelector: make(map[[4]uint32]uint32),
+		digitSelector:  make(map[[8]uint32]uint32),
+	}
+	var tables [3][4][numMaps]int
+	for _, m := range modes {
+		for space := x86db.EncodingLegacy; space <= x86db.EncodingEVEX; space++ {
+			for om := 0; om < numMaps; om++ {
+				var t [256]uint32
+				used := false
+				for opcode := 0; opcode < 256; opcode++ {
+					gr := grids[key{m.index, space, x86db.OpcodeMap(om), byte(opcode)}]
+					if gr == nil {
+						continue
+					}
+					used = true
+					t[opcode] = g.opcodeEntry(gr, space == x86db.EncodingLegacy)
+				}
+				tables[m.index][space][om] = -1
+				if used {
+					tables[m.index][space][om] = g.table(t)
+				}
+			}
+		}
+	}
+
+	for c := range conflicts {
+		log.Printf("line %d conflicts with line %d", c[1], c[0])
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// generated by x86db-ildgen\n// DO NOT EDIT\n\npackage ild\n\n")
+	fmt.Fprintf(&buf, "const (\n")
+	for i, name := range spaceNames {
+		fmt.Fprintf(&buf, "%s = %d\n", name, i)
+	}
+	fmt.Fprintf(&buf, "numMaps = %d\n)\n\n", numMaps)
+
+	fmt.Fprintf(&buf, "var selectors = [...]uint32{")
+	for i, e := range g.selectors {
+		if i%8 == 0 {
+			fmt.Fprintf(&buf, "\n")
+		}
+		fmt.Fprintf(&buf, "%#x, ", e)
+	}
+	fmt.Fprintf(&buf, "\n}\n\n")
+
+	for n, t := range g.tables {
+		fmt.Fprintf(&buf, "var table%d = [256]uint32{", n)
+		for i, e := range t {
+			if i%8 == 0 {
+				fmt.Fprintf(&buf, "\n")
+			}
+			fmt.Fprintf(&buf, "%#x, ", e)
+		}
+		fmt.Fprintf(&buf, "\n}\n\n")
+	}
+
+	fmt.Fprintf(&buf, "// tables is indexed by mode, encoding space and opcode map.\n")
+	fmt.Fprintf(&buf, "var tables = [3][4][numMaps]*[256]uint32{\n")
+	for _, m := range modes {
+		fmt.Fprintf(&buf, "{ // %d-bit\n", m.bits)
+		for space := range tables[m.index] {
+			fmt.Fprintf(&buf, "{")
+			for _, n := range tables[m.index][space] {
+				if n < 0 {
+					fmt.Fprintf(&buf, "nil, ")
+					continue
+				}
+				fmt.Fprintf(&buf, "&table%d, ", n)
+			}
+			fmt.Fprintf(&buf, "},\n")
+		}
+		fmt.Fprintf(&buf, "},\n")
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
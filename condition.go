@@ -0,0 +1,166 @@
+package x86db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Condition is a condition code of the Jcc, CMOVcc and SETcc instructions,
+// see Code for its encoding.
+type Condition uint8
+
+const (
+	// ConditionNone is the Condition of instructions without a condition
+	// code.
+	ConditionNone Condition = iota
+	ConditionO
+	ConditionNO
+	ConditionB
+	ConditionAE
+	ConditionE
+	ConditionNE
+	ConditionBE
+	ConditionA
+	ConditionS
+	ConditionNS
+	ConditionP
+	ConditionNP
+	ConditionL
+	ConditionGE
+	ConditionLE
+	ConditionG
+)
+
+// ConditionInfo describes a condition code. The first name is the canonical
+// one, the others are aliases.
+type ConditionInfo struct {
+	Condition Condition
+	Names     []string
+	Help      string
+}
+
+// ConditionList lists all the condition codes, in encoding order.
+var ConditionList = []ConditionInfo{
+	{ConditionO, []string{"O"}, "Overflow (OF=1)"},
+	{ConditionNO, []string{"NO"}, "No overflow (OF=0)"},
+	{ConditionB, []string{"B", "C", "NAE"}, "Below (CF=1)"},
+	{ConditionAE, []string{"AE", "NB", "NC"}, "Above or equal (CF=0)"},
+	{ConditionE, []string{"E", "Z"}, "Equal (ZF=1)"},
+	{ConditionNE, []string{"NE", "NZ"}, "Not equal (ZF=0)"},
+	{ConditionBE, []string{"BE", "NA"}, "Below or equal (CF=1 or ZF=1)"},
+	{ConditionA, []string{"A", "NBE"}, "Above (CF=0 and ZF=0)"},
+	{ConditionS, []string{"S"}, "Sign (SF=1)"},
+	{ConditionNS, []string{"NS"}, "No sign (SF=0)"},
+	{ConditionP, []string{"P", "PE"}, "Parity even (PF=1)"},
+	{ConditionNP, []string{"NP", "PO"}, "Parity odd (PF=0)"},
+	{ConditionL, []string{"L", "NGE"}, "Less (SF!=OF)"},
+	{ConditionGE, []string{"GE", "NL"}, "Greater or equal (SF=OF)"},
+	{ConditionLE, []string{"LE", "NG"}, "Less or equal (ZF=1 or SF!=OF)"},
+	{ConditionG, []string{"G", "NLE"}, "Greater (ZF=0 and SF=OF)"},
+}
+
+// ConditionFromString returns the condition code with the given name or
+// alias, eg. "Z". The comparison is case insensitive.
+func ConditionFromString(name string) (Condition, error) {
+	name = strings.ToUpper(name)
+	for _, info := range ConditionList {
+		for _, n := range info.Names {
+			if n == name {
+				return info.Condition, nil
+			}
+		}
+	}
+
+	return ConditionNone, fmt.Errorf("no Condition with name '%s'", name)
+}
+
+// info returns the ConditionList entry of c, nil for ConditionNone and
+// invalid values.
+func (c Condition) info() *ConditionInfo {
+	if c < ConditionO || int(c) > len(ConditionList) {
+		return nil
+	}
+	return &ConditionList[c-ConditionO]
+}
+
+// String implements the stringer interface for Condition. It returns the
+// canonical name of the condition code.
+func (c Condition) String() string {
+	info := c.info()
+	if info == nil {
+		return fmt.Sprintf("Condition(%d)", int(c))
+	}
+	return info.Names[0]
+}
+
+// Names returns the canonical name and aliases of the condition code.
+func (c Condition) Names() []string {
+	info := c.info()
+	if info == nil {
+		return nil
+	}
+	return info.Names
+}
+
+// Code returns the condition code as encoded in the low 4 bits of the opcode.
+func (c Condition) Code() byte {
+	return byte(c-ConditionO) & 0xf
+}
+
+// Negate returns the opposite condition code, eg. NE for E.
+func (c Condition) Negate() Condition {
+	if c.info() == nil {
+		return c
+	}
+	return ConditionO + Condition(c.Code()^1)
+}
+
+// conditionSuffix is the suffix of the mnemonic of condition code templates.
+const conditionSuffix = "cc"
+
+// isConditionTemplate returns true if insn is a condition code template,
+// eg. "Jcc".
+func isConditionTemplate(insn *Instruction) bool {
+	return insn.Encoding.Add == AddCondition &&
+		strings.HasSuffix(insn.Name, conditionSuffix)
+}
+
+// expandConditions expands a condition code template into one concrete
+// instruction per condition code name, aliases included.
+func expandConditions(template *Instruction) []Instruction {
+	prefix := strings.TrimSuffix(template.Name, conditionSuffix)
+
+	// The opcode the condition code applies to is the last one of the
+	// Opcodes string and the Opcode slice. The condition code is xor'ed
+	// in, so the "jcc over jmp" form, 71+c, inverts the condition.
+	code := -1
+	for i, opcode := range template.Pattern.Opcodes {
+		if strings.HasSuffix(opcode, "+c") {
+			code = i
+		}
+	}
+	last := len(template.Encoding.Opcode) - 1
+
+	var insns []Instruction
+	for _, info := range ConditionList {
+		opcode := template.Encoding.Opcode[last] ^ info.Condition.Code()
+
+		for _, name := range info.Names {
+			insn := *template
+			insn.Name = prefix + name
+			insn.Condition = info.Condition
+			insn.Template = template
+
+			insn.Pattern.Opcodes = append([]string(nil), template.Pattern.Opcodes...)
+			insn.Pattern.Opcodes[code] = fmt.Sprintf("%02x", opcode)
+
+			insn.Encoding.Opcode = append([]byte(nil), template.Encoding.Opcode...)
+			insn.Encoding.Opcode[last] = opcode
+			insn.Encoding.Add = AddNone
+
+			insns = append(insns, insn)
+		}
+	}
+
+	return insns
+}
@@ -0,0 +1,95 @@
+package x86db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionFromString(t *testing.T) {
+	tests := []struct {
+		input  string
+		valid  bool
+		golden Condition
+	}{
+		{"O", true, ConditionO},
+		{"z", true, ConditionE},
+		{"NAE", true, ConditionB},
+		{"PO", true, ConditionNP},
+		{"NLE", true, ConditionG},
+		{"X", false, ConditionNone},
+	}
+
+	for _, test := range tests {
+		cc, err := ConditionFromString(test.input)
+		if !test.valid {
+			assert.NotNil(t, err)
+			continue
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, test.golden, cc)
+	}
+
+	for i, info := range ConditionList {
+		assert.Equal(t, Condition(i+1), info.Condition)
+		assert.Equal(t, byte(i), info.Condition.Code())
+	}
+	assert.Equal(t, ConditionNE, ConditionE.Negate())
+	assert.Equal(t, ConditionO, ConditionNO.Negate())
+	assert.Equal(t, "AE", ConditionAE.String())
+	assert.Equal(t, []string{"E", "Z"}, ConditionE.Names())
+
+	// Out of range values.
+	assert.Equal(t, "Condition(0)", ConditionNone.String())
+	assert.Equal(t, "Condition(17)", Condition(17).String())
+	assert.Nil(t, Condition(17).Names())
+	assert.Equal(t, ConditionNone, ConditionNone.Negate())
+}
+
+func TestExpandConditions(t *testing.T) {
+	db := NewDBWithOptions("", LoadOptions{ExpandConditions: true})
+	assert.Nil(t, db.Open())
+
+	tests := []struct {
+		name, operands, opcodes string
+		template                string
+		condition               Condition
+		opcode                  []byte
+	}{
+		{"JNE", "imm", "70+c rel8", "Jcc", ConditionNE, []byte{0x75}},
+		{"JZ", "imm|near", "odf 0f 80+c rel", "Jcc", ConditionE, []byte{0x84}},
+		{"JNE", "imm", "71+c jlen e9 rel", "Jcc", ConditionNE, []byte{0x74}},
+		{"CMOVGE", "reg32,reg32", "o32 0f 40+c /r", "CMOVcc", ConditionGE, []byte{0x4d}},
+		{"SETC", "reg8", "0f 90+c /0", "SETcc", ConditionB, []byte{0x92}},
+	}
+
+	for _, test := range tests {
+		var insn *Instruction
+		for i := range db.Instructions {
+			candidate := &db.Instructions[i]
+			if candidate.Name == test.name &&
+				candidate.Template != nil &&
+				strings.Join(candidate.Template.Pattern.Opcodes, " ") == test.opcodes &&
+				strings.Join(candidate.Operands, ",") == test.operands {
+				insn = candidate
+			}
+		}
+		if !assert.NotNil(t, insn, test.name) {
+			continue
+		}
+		assert.Equal(t, test.template, insn.Template.Name)
+		assert.Equal(t, test.condition, insn.Condition)
+		assert.Equal(t, test.opcode, insn.Encoding.Opcode)
+		assert.Equal(t, AddNone, insn.Encoding.Add)
+		assert.NotContains(t, strings.Join(insn.Pattern.Opcodes, " "), "+c")
+	}
+
+	// Templates are replaced by their expansion.
+	for _, insn := range db.Instructions {
+		assert.False(t, strings.HasSuffix(insn.Name, "cc"), insn.Name)
+		if insn.Template == nil {
+			assert.Equal(t, ConditionNone, insn.Condition, insn.Name)
+		}
+	}
+}
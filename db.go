@@ -3,19 +3,76 @@ package x86db
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 // DB holds the list of known instructions
 type DB struct {
 	instructionsFile string
+	options          LoadOptions
+	groups           []Group
+	diagnostics      []Diagnostic
+	aliases          map[string]Alias
+	index            index
 	Instructions     InstructionSlice
 }
 
+// LoadMode selects how invalid lines are handled when loading instructions.
+type LoadMode int
+
+const (
+	// LoadStrict stops loading at the first invalid line.
+	LoadStrict LoadMode = iota
+	// LoadLenient skips invalid lines, recording them in the diagnostics.
+	LoadLenient
+)
+
+// LoadOptions tunes how the instructions file is loaded.
+type LoadOptions struct {
+	Mode LoadMode
+	// ExpandConditions replaces the condition code templates, eg. "Jcc",
+	// with one instruction per condition code name, eg. "JNE", "JNZ".
+	ExpandConditions bool
+}
+
+// Severity is the severity of a Diagnostic.
+type Severity int
+
+const (
+	// SeverityWarning is used for problems that don't prevent an
+	// instruction from being loaded, eg. an unknown flag.
+	SeverityWarning Severity = iota
+	// SeverityError is used for lines that couldn't be loaded.
+	SeverityError
+)
+
+var severityNames = []string{"warning", "error"}
+
+// String implements the stringer interface for Severity.
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// Diagnostic is a problem found while loading instructions.
+type Diagnostic struct {
+	Severity Severity
+	*ParseError
+}
+
+// String implements the stringer interface for Diagnostic.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Severity, d.ParseError)
+}
+
 // NewDB creates a new DB object.
 func NewDB() *DB {
 	return NewDBFromFile("")
@@ -24,8 +81,15 @@ func NewDB() *DB {
 // NewDBFromFile creates a new DB object, loading the list of instructions from
 // instructionsFile. The format of instructionsFile is the nasm one.
 func NewDBFromFile(instructionsFile string) *DB {
+	return NewDBWithOptions(instructionsFile, LoadOptions{})
+}
+
+// NewDBWithOptions creates a new DB object loading instructionsFile, or the
+// bundled insns.dat if empty, according to options.
+func NewDBWithOptions(instructionsFile string, options LoadOptions) *DB {
 	return &DB{
 		instructionsFile: instructionsFile,
+		options:          options,
 	}
 }
 
@@ -42,13 +106,180 @@ func ignoreInstruction(flag string) bool {
 	return false
 }
 
-func (db *DB) readInstructions(r io.Reader) error {
+// sectionExtensions lists the extensions implied by the insns.dat section an
+// instruction is in, for extensions insns.dat doesn't have a flag for.
+var sectionExtensions = []struct {
+	section    string
+	extensions []Extension
+}{
+	{"XSAVE group", []Extension{ExtensionXSAVE}},
+	{"Generic memory operations", []Extension{ExtensionSSE}},
+	{"AMD Enhanced 3DNow!", []Extension{Extension3DNOWEXT}},
+	{"New instructions in Barcelona", []Extension{ExtensionLZCNT}},
+	{"Intel SMX", []Extension{ExtensionSMX}},
+	{"Geode (Cyrix) 3DNow! additions", []Extension{ExtensionGEODE}},
+	{"Intel new instructions in ???", []Extension{ExtensionMOVBE}},
+	{"Intel AES instructions", []Extension{ExtensionAES}},
+	{"Intel AVX AES instructions", []Extension{ExtensionAES}},
+	{"Intel Carry-Less Multiplication", []Extension{ExtensionCLMUL}},
+	{"Intel AVX Carry-Less Multiplication", []Extension{ExtensionCLMUL}},
+	{"VIA (Centaur) security instructions", []Extension{ExtensionPADLOCK}},
+	{"AMD Lightweight Profiling", []Extension{ExtensionLWP}},
+	{"Intel memory protection keys", []Extension{ExtensionPKU}},
+	{"Read Processor ID", []Extension{ExtensionRDPID}},
+	{"Systematic names for the hinting nop", []Extension{ExtensionHINTNOP}},
+}
+
+// mnemonicExtensions lists the extensions of instructions found in sections
+// mixing several extensions.
+var mnemonicExtensions = map[string]Extension{
+	"MOVNTI":     ExtensionSSE2,
+	"POPCNT":     ExtensionPOPCNT,
+	"RDFSBASE":   ExtensionFSGSBASE,
+	"RDGSBASE":   ExtensionFSGSBASE,
+	"WRFSBASE":   ExtensionFSGSBASE,
+	"WRGSBASE":   ExtensionFSGSBASE,
+	"RDRAND":     ExtensionRDRAND,
+	"RDSEED":     ExtensionRDSEED,
+	"VCVTPH2PS":  ExtensionF16C,
+	"VCVTPS2PH":  ExtensionF16C,
+	"ADCX":       ExtensionADX,
+	"ADOX":       ExtensionADX,
+	"CLAC":       ExtensionSMAP,
+	"STAC":       ExtensionSMAP,
+	"CLFLUSHOPT": ExtensionCLFLUSHOPT,
+	"CLWB":       ExtensionCLWB,
+	"CLZERO":     ExtensionCLZERO,
+	"KADDW":      ExtensionAVX512DQ,
+	"KTESTW":     ExtensionAVX512DQ,
+	"KUNPCKBW":   ExtensionAVX512,
+	"KUNPCKWD":   ExtensionAVX512BW,
+	"KUNPCKDQ":   ExtensionAVX512BW,
+
+	// The HLE flag of XTEST is read as AttrHLE.
+	"XTEST": ExtensionHLE,
+}
+
+// maskInstructionExtension returns the extension of the AVX-512 opmask
+// instructions without their own entry in mnemonicExtensions: word forms are
+// part of AVX-512F, byte forms of AVX512DQ and dword/qword forms of AVX512BW.
+func maskInstructionExtension(name string) Extension {
+	switch name[len(name)-1] {
+	case 'W':
+		return ExtensionAVX512
+	case 'B':
+		return ExtensionAVX512DQ
+	}
+	return ExtensionAVX512BW
+}
+
+// impliedExtensions returns the extensions of an instruction that can't be
+// derived from its flags.
+func impliedExtensions(section, name string, encoding *Encoding) ExtensionSet {
+	var extensions ExtensionSet
+
+	for _, s := range sectionExtensions {
+		if strings.HasPrefix(section, s.section) {
+			extensions = extensions.Union(NewExtensionSet(s.extensions...))
+		}
+	}
+
+	if e, ok := mnemonicExtensions[name]; ok {
+		extensions.Add(e)
+	} else if section == "AVX-512 mask register instructions" {
+		extensions.Add(maskInstructionExtension(name))
+	}
+
+	// The SSE5 section holds both the XOP and FMA4 instructions, FMA4 ones
+	// use a VEX prefix.
+	if strings.HasPrefix(section, "AMD XOP and FMA4") {
+		if encoding.Space == EncodingXOP {
+			extensions.Add(ExtensionXOP)
+		} else {
+			extensions.Add(ExtensionFMA4)
+		}
+	}
+
+	return extensions
+}
+
+// ParseError describes a problem found while reading an instructions file.
+type ParseError struct {
+	File string
+	// Line and Column are 1-based. Column points at the offending text.
+	Line   int
+	Column int
+	// Text is the offending part of the line.
+	Text   string
+	Reason string
+}
+
+// Error implements the error interface for ParseError.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s: '%s'", e.File, e.Line, e.Column,
+		e.Reason, e.Text)
+}
+
+// countFields returns the number of fields of line, counting a bracketed
+// pattern as a single field like readInstructions does.
+func countFields(line string) int {
+	n := 0
+	for rest := strings.TrimLeft(line, " \t"); rest != ""; n++ {
+		end := strings.IndexAny(rest, " \t")
+		if rest[0] == '[' {
+			if i := strings.IndexByte(rest, ']'); i >= 0 {
+				end = i + 1
+			}
+		}
+		if end < 0 {
+			end = len(rest)
+		}
+		rest = strings.TrimLeft(rest[end:], " \t")
+	}
+	return n
+}
+
+// tokenIndex returns the index of token in the pattern field, -1 if it isn't
+// there.
+func tokenIndex(field, token string) int {
+	isSeparator := func(c byte) bool {
+		return strings.IndexByte(" \t[]:", c) >= 0
+	}
+	for i := 0; i+len(token) <= len(field); i++ {
+		if field[i:i+len(token)] == token &&
+			(i == 0 || isSeparator(field[i-1])) &&
+			(i+len(token) == len(field) || isSeparator(field[i+len(token)])) {
+			return i
+		}
+	}
+	return -1
+}
+
+// bundledFile is the file name used in positions when reading the insns.dat
+// bundled with the package.
+const bundledFile = "data/insns.dat"
+
+func (db *DB) readInstructions(file string, r io.Reader) error {
 	pattern := regexp.MustCompile(`^\s*(\S+)\s+(\S+)\s+(\S+|\[.*\])\s+(\S+)\s*$`)
 
+	var group Group
+
 	scanner := bufio.NewScanner(r)
+	lineno := 0
 next:
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineno++
+
+		// ";#" comments are section headers
+		if strings.HasPrefix(line, ";#") {
+			group = Group{
+				Title:   strings.TrimSpace(line[2:]),
+				Ordinal: len(db.groups) + 1,
+			}
+			db.groups = append(db.groups, group)
+			continue
+		}
 
 		// strip comments
 		idx := strings.IndexRune(line, ';')
@@ -56,64 +287,199 @@ next:
 			line = line[0:idx]
 		}
 
-		if line == "" {
+		if strings.TrimSpace(line) == "" {
 			continue
 		}
 
-		fields := pattern.FindSubmatch([]byte(line))
+		// at returns a ParseError pointing at line[start:end].
+		at := func(start, end int, format string, a ...interface{}) *ParseError {
+			return &ParseError{
+				File:   file,
+				Line:   lineno,
+				Column: start + 1,
+				Text:   line[start:end],
+				Reason: fmt.Sprintf(format, a...),
+			}
+		}
+
+		// reject records an invalid line. It returns the error to stop
+		// loading with or nil when the line should be skipped.
+		reject := func(e *ParseError) error {
+			if db.options.Mode == LoadStrict {
+				return e
+			}
+			db.diagnostics = append(db.diagnostics, Diagnostic{SeverityError, e})
+			return nil
+		}
+
+		warn := func(e *ParseError) {
+			db.diagnostics = append(db.diagnostics, Diagnostic{SeverityWarning, e})
+		}
+
+		loc := pattern.FindStringSubmatchIndex(line)
 		// We want 4 fields
-		if len(fields) != 5 {
-			return fmt.Errorf("readInstructions: expected 4 fields got %d", len(fields)-1)
+		if loc == nil {
+			start := len(line) - len(strings.TrimLeft(line, " \t"))
+			end := len(strings.TrimRight(line, " \t"))
+			err := reject(at(start, end, "expected 4 fields got %d",
+				countFields(line)))
+			if err != nil {
+				return err
+			}
+			continue
 		}
+		fields := make([]string, 5)
+		for i := range fields {
+			fields[i] = line[loc[2*i]:loc[2*i+1]]
+		}
+
+		// field returns a ParseError pointing at the nth field.
+		field := func(n int, format string, a ...interface{}) *ParseError {
+			return at(loc[2*n], loc[2*n+1], format, a...)
+		}
+
+		// patternError returns a ParseError pointing at the token of the
+		// pattern err is about, or at the whole pattern.
+		patternError := func(err error) *ParseError {
+			var terr *tokenError
+			if errors.As(err, &terr) {
+				if i := tokenIndex(fields[3], terr.token); i >= 0 {
+					start := loc[6] + i
+					return at(start, start+len(terr.token), "%v", err)
+				}
+			}
+			return field(3, "%v", err)
+		}
+
+		// The 3rd field is the instruction pattern. Pseudo-instructions
+		// like EQU don't have one.
+		pattern := &Pattern{}
+		if fields[3] != "ignore" {
+			if len(fields[3]) < 2 || fields[3][0] != '[' ||
+				fields[3][len(fields[3])-1] != ']' {
+				if err := reject(field(3, "pattern not enclosed in brackets")); err != nil {
+					return err
+				}
+				continue
+			}
 
-		// The 3rd field is the instruction pattern
-		pattern, err := patternFromString(string(fields[3][1 : len(fields[3])-1]))
+			var err error
+			pattern, err = patternFromString(fields[3][1 : len(fields[3])-1])
+			if err != nil {
+				if err := reject(patternError(err)); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		encoding, err := pattern.Encoding()
 		if err != nil {
-			return err
+			if err := reject(patternError(err)); err != nil {
+				return err
+			}
+			continue
 		}
 
 		// The 4th field holds misc, comma separated, flags.
 		var opSizeFlags OpSize
-		var extension Extension
-		for _, field := range strings.Split(string(fields[4]), ",") {
-			if ignoreInstruction(field) {
-				goto next
+		var attr Attr
+		var extensions ExtensionSet
+		var cpuLevel CPULevel
+		x64 := false
+		offset := loc[8]
+		for _, flag := range strings.Split(fields[4], ",") {
+			start := offset
+			offset += len(flag) + 1
+
+			if ignoreInstruction(flag) {
+				continue next
 			}
 
-			f, err := opSizeFromString(field)
+			f, err := opSizeFromString(flag)
 			if err == nil {
 				opSizeFlags |= f
 				continue
 			}
 
-			e, err := ExtensionFromString(field)
+			a, err := AttrFromString(flag)
 			if err == nil {
-				extension = e
+				attr |= a
 				continue
 			}
+
+			e, err := ExtensionFromString(flag)
+			if err == nil {
+				extensions.Add(e)
+				continue
+			}
+
+			l, err := CPULevelFromString(flag)
+			if err == nil {
+				if l == CPULevelX64 {
+					x64 = true
+				}
+				if l > cpuLevel {
+					cpuLevel = l
+				}
+				continue
+			}
+
+			warn(at(start, start+len(flag), "unknown flag"))
 		}
 
+		operands := strings.Split(fields[2], ",")
+		args, errs := operandsFromStrings(operands, pattern)
+		for _, e := range errs {
+			start := loc[4] + e.offset
+			perr := at(start, start+len(e.operand), "%v", e.err)
+			if db.options.Mode == LoadStrict {
+				return perr
+			}
+			warn(perr)
+		}
+
+		extensions = extensions.Union(impliedExtensions(group.Title,
+			fields[1], encoding))
+
 		instruction := Instruction{
-			Name:      string(fields[1]),
-			Operands:  strings.Split(string(fields[2]), ","),
-			Pattern:   *pattern,
-			Flags:     string(fields[4]),
-			Extension: extension,
-			OpSize:    opSizeFlags,
+			Name:       fields[1],
+			Operands:   operands,
+			Args:       args,
+			Pattern:    *pattern,
+			Encoding:   *encoding,
+			Flags:      fields[4],
+			Extensions: extensions,
+			CPULevel:   cpuLevel,
+			OpSize:     opSizeFlags,
+			Attr:       attr,
+			Group:      group,
+			Modes:      instructionModes(x64, attr, encoding, args),
+			File:       file,
+			Line:       lineno,
 		}
+
+		if db.options.ExpandConditions && isConditionTemplate(&instruction) {
+			template := instruction
+			db.Instructions = append(db.Instructions, expandConditions(&template)...)
+			continue
+		}
+
 		db.Instructions = append(db.Instructions, instruction)
 	}
 
-	return nil
+	return scanner.Err()
 }
 
 // Open loads instructions from disk.
 func (db *DB) Open() error {
 	var r io.Reader
+	file := db.instructionsFile
 
-	if db.instructionsFile == "" {
+	if file == "" {
 		// Use the insns.dat bundled with the package
-		data, err := Asset("data/insns.dat")
+		file = bundledFile
+		data, err := Asset(bundledFile)
 		if err != nil {
 			return err
 		}
@@ -121,7 +487,7 @@ func (db *DB) Open() error {
 		r = bytes.NewReader(data)
 	} else {
 		// db file provided by the user
-		f, err := os.Open(db.instructionsFile)
+		f, err := os.Open(file)
 		if err != nil {
 			return err
 		}
@@ -130,22 +496,107 @@ func (db *DB) Open() error {
 		r = f
 	}
 
-	if err := db.readInstructions(r); err != nil {
+	if err := db.readInstructions(file, r); err != nil {
 		return err
 	}
 
+	db.buildAliases()
+	db.buildIndex()
+
 	return nil
 }
 
+// Diagnostics returns the problems found when loading the instructions. In
+// strict mode, only warnings are recorded as the first error stops loading.
+func (db *DB) Diagnostics() []Diagnostic {
+	return db.diagnostics
+}
+
 // Close closes the DB precious resources.
 func (db *DB) Close() {
 
 }
 
 // FindByExtension returns the list of instructions introduced as part of the
-// specificied extension.
+// specificied extension. ExtensionBase selects the instructions not part of
+// any extension.
 func (db *DB) FindByExtension(extension Extension) InstructionSlice {
+	return db.index.byExtension[extension]
+}
+
+// FindByExtensions returns the list of instructions requiring all of the
+// specified extensions, eg. AVX512VL and AVX512BW.
+func (db *DB) FindByExtensions(extensions ...Extension) InstructionSlice {
+	if len(extensions) == 0 {
+		return db.Instructions
+	}
+
+	set := NewExtensionSet(extensions...)
+	return db.FindByExtension(extensions[0]).Where(func(insn Instruction) bool {
+		return insn.Extensions.HasAll(set)
+	})
+}
+
+// FindByCPULevel returns the list of instructions available on processors of
+// the given level, ie. the instructions introduced at or below level.
+// Instructions without a CPU level in insns.dat aren't returned.
+func (db *DB) FindByCPULevel(level CPULevel) InstructionSlice {
+	return db.Instructions.Where(func(insn Instruction) bool {
+		return insn.CPULevel != CPULevelNone && insn.CPULevel <= level
+	})
+}
+
+// FindByAttr returns the list of instructions with all the attributes of attr
+// set.
+func (db *DB) FindByAttr(attr Attr) InstructionSlice {
 	return db.Instructions.Where(func(insn Instruction) bool {
-		return insn.Extension == extension
+		return insn.Attr.Has(attr)
 	})
 }
+
+// FindByMode returns the list of instructions that can be assembled in all
+// the execution modes of mode.
+func (db *DB) FindByMode(mode Modes) InstructionSlice {
+	return db.Instructions.Where(func(insn Instruction) bool {
+		return insn.Modes.Has(mode)
+	})
+}
+
+// Groups returns the list of instruction groups, in insns.dat order.
+func (db *DB) Groups() []Group {
+	return db.groups
+}
+
+// GroupFromString returns the group with the given ordinal or whose title
+// starts with name. The title comparison is case insensitive.
+func (db *DB) GroupFromString(name string) (Group, error) {
+	if ordinal, err := strconv.Atoi(name); err == nil {
+		if ordinal < 1 || ordinal > len(db.groups) {
+			return Group{}, fmt.Errorf("no Group with ordinal %d", ordinal)
+		}
+		return db.groups[ordinal-1], nil
+	}
+
+	var found []Group
+	for _, g := range db.groups {
+		if strings.HasPrefix(strings.ToLower(g.Title), strings.ToLower(name)) {
+			found = append(found, g)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return Group{}, fmt.Errorf("no Group with name '%s'", name)
+	case 1:
+		return found[0], nil
+	}
+	return Group{}, fmt.Errorf("ambiguous Group name '%s'", name)
+}
+
+// FindByGroup returns the list of instructions listed in group.
+func (db *DB) FindByGroup(group Group) InstructionSlice {
+	if group.Ordinal < 0 || group.Ordinal >= len(db.index.byGroup) {
+		return nil
+	}
+	return db.index.byGroup[group.Ordinal]
+}
@@ -1,6 +1,8 @@
 package x86db
 
 import (
+	"errors"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -18,7 +20,9 @@ func TestReadInstructions(t *testing.T) {
 			Instruction{
 				Name:     "SBB",
 				Operands: []string{"reg_eax", "sbytedword"},
+				CPULevel: CPULevel386,
 				OpSize:   OpSizeSM,
+				Attr:     AttrND,
 			},
 		},
 		{
@@ -26,24 +30,54 @@ func TestReadInstructions(t *testing.T) {
 			Instruction{
 				Name:     "MOV",
 				Operands: []string{"reg64", "sdword"},
+				CPULevel: CPULevelX64,
 				OpSize:   OpSizeSM | OpSizeOPT,
+				Attr:     AttrND,
 			},
 		},
 		{
 			`ADDPS  xmmreg,xmmrm128       [rm:    np 0f 58 /r]     KATMAI,SSE`, true,
 			Instruction{
-				Name:      "ADDPS",
-				Operands:  []string{"xmmreg", "xmmrm128"},
-				Extension: ExtensionSSE,
+				Name:       "ADDPS",
+				Operands:   []string{"xmmreg", "xmmrm128"},
+				Extensions: NewExtensionSet(ExtensionSSE),
+				CPULevel:   CPULevelKATMAI,
 			},
 		},
+		{
+			`VANDNPD xmmreg|mask|z,xmmreg*,xmmrm128|b64 [rvm:fv: evex.nds.128.66.0f.w1 55 /r] AVX512VL,AVX512DQ,FUTURE`, true,
+			Instruction{
+				Name:       "VANDNPD",
+				Operands:   []string{"xmmreg|mask|z", "xmmreg*", "xmmrm128|b64"},
+				Extensions: NewExtensionSet(ExtensionAVX512VL, ExtensionAVX512DQ),
+				CPULevel:   CPULevelFUTURE,
+			},
+		},
+		{
+			`VADDPD zmmreg|mask|z,zmmreg*,zmmrm512|b64|er [rvm:fv: evex.nds.512.66.0f.w1 58 /r] AVX512,FUTURE`, true,
+			Instruction{
+				Name:       "VADDPD",
+				Operands:   []string{"zmmreg|mask|z", "zmmreg*", "zmmrm512|b64|er"},
+				Extensions: NewExtensionSet(ExtensionAVX512),
+				CPULevel:   CPULevelFUTURE,
+				Pattern: Pattern{
+					Operands:  "rvm",
+					TupleType: TupleFV,
+					Opcodes:   []string{"evex.nds.512.66.0f.w1", "58", "/r"},
+				},
+			},
+		},
+		{
+			`VADDPD zmmreg,zmmreg,zmmrm512 [rvm:xx: evex.nds.512.66.0f.w1 58 /r] AVX512`, false,
+			Instruction{},
+		},
 	}
 
 	for _, test := range tests {
 		r := strings.NewReader(test.input)
 		db := DB{}
 
-		err := db.readInstructions(r)
+		err := db.readInstructions("test.dat", r)
 		if !test.valid {
 			assert.NotNil(t, err)
 			continue
@@ -58,5 +92,293 @@ func TestReadInstructions(t *testing.T) {
 		assert.Equal(t, g.Name, parsed.Name)
 		assert.Equal(t, g.Operands, parsed.Operands)
 		assert.Equal(t, g.OpSize, parsed.OpSize)
+		assert.Equal(t, g.Extensions, parsed.Extensions)
+		assert.Equal(t, g.CPULevel, parsed.CPULevel)
+		assert.Equal(t, g.Attr, parsed.Attr)
+		assert.Equal(t, "test.dat", parsed.File)
+		assert.Equal(t, 1, parsed.Line)
+		if g.Pattern.Opcodes != nil {
+			assert.Equal(t, g.Pattern, parsed.Pattern)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		input  string
+		line   int
+		column int
+		text   string
+		reason string
+	}{
+		{"; comment\nNOP void [ 90] 8086\nPAUSE void", 3, 1, "PAUSE void",
+			"expected 4 fields got 2"},
+		{"NOP    void    [ 90 /r ]", 1, 1, "NOP    void    [ 90 /r ]",
+			"expected 4 fields got 3"},
+		{"NOP    void    90    8086", 1, 16, "90",
+			"pattern not enclosed in brackets"},
+		{"NOP    void    [ 90 /q]    8086", 1, 21, "/q", "unknown code '/q'"},
+		{"NOP    void    [ 90+x ]    8086", 1, 18, "90+x", "unknown code '90+x'"},
+		{"VNOP   void    [rm:t9: evex.512.0f 90 /r]    FUTURE", 1, 20, "t9",
+			"no TupleType with name 't9'"},
+		{"VNOP   void    [rm: evex.512.0f.q9 90 /r]    FUTURE", 1, 21,
+			"evex.512.0f.q9", "unknown field 'q9' in 'evex.512.0f.q9'"},
+		{";# Section\n\nFOO    reg99    [r: 90]    8086", 3, 8, "reg99", ""},
+	}
+
+	for _, test := range tests {
+		db := DB{}
+		err := db.readInstructions("test.dat", strings.NewReader(test.input))
+
+		var perr *ParseError
+		assert.True(t, errors.As(err, &perr), test.input)
+		if perr == nil {
+			continue
+		}
+		assert.Equal(t, "test.dat", perr.File)
+		assert.Equal(t, test.line, perr.Line, test.input)
+		assert.Equal(t, test.column, perr.Column, test.input)
+		assert.Equal(t, test.text, perr.Text, test.input)
+		if test.reason != "" {
+			assert.Equal(t, test.reason, perr.Reason, test.input)
+		} else {
+			assert.NotEqual(t, "", perr.Reason)
+		}
+	}
+}
+
+func TestLenientLoading(t *testing.T) {
+	input := `NOP    void          [ 90]          8086,FOO
+PAUSE  void
+ADD    reg8,reg99    [mr: 00 /r]    8086
+SUB    void          [ 90 /q]       8086
+INT3   void          [ cc]          8086`
+
+	db := NewDBWithOptions("", LoadOptions{Mode: LoadLenient})
+	assert.Nil(t, db.readInstructions("test.dat", strings.NewReader(input)))
+
+	var names []string
+	for _, insn := range db.Instructions {
+		names = append(names, insn.Name)
+	}
+	assert.Equal(t, []string{"NOP", "ADD", "INT3"}, names)
+
+	diags := db.Diagnostics()
+	assert.Equal(t, 4, len(diags))
+	golden := []struct {
+		severity Severity
+		line     int
+		column   int
+		text     string
+	}{
+		{SeverityWarning, 1, 42, "FOO"},
+		{SeverityError, 2, 1, "PAUSE  void"},
+		{SeverityWarning, 3, 13, "reg99"},
+		{SeverityError, 4, 27, "/q"},
+	}
+	for i, g := range golden {
+		if i >= len(diags) {
+			break
+		}
+		assert.Equal(t, g.severity, diags[i].Severity)
+		assert.Equal(t, g.line, diags[i].Line)
+		assert.Equal(t, g.column, diags[i].Column)
+		assert.Equal(t, g.text, diags[i].Text)
+	}
+
+	// The same input stops at the first error in strict mode, keeping the
+	// warnings found until then.
+	db = NewDB()
+	err := db.readInstructions("test.dat", strings.NewReader(input))
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(db.Diagnostics()))
+}
+
+func TestSeverityString(t *testing.T) {
+	assert.Equal(t, "warning", SeverityWarning.String())
+	assert.Equal(t, "error", SeverityError.String())
+	assert.Equal(t, "Severity(2)", Severity(2).String())
+	assert.Equal(t, "Severity(-1)", Severity(-1).String())
+}
+
+func TestOpenBundled(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+	assert.NotEqual(t, 0, len(db.Instructions))
+	assert.Empty(t, db.Diagnostics())
+	for _, insn := range db.Instructions {
+		assert.Equal(t, bundledFile, insn.File)
+		assert.NotEqual(t, 0, insn.Line)
+	}
+}
+
+func TestFindByExtensions(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+
+	vl := db.FindByExtension(ExtensionAVX512VL)
+	bw := db.FindByExtension(ExtensionAVX512BW)
+	both := db.FindByExtensions(ExtensionAVX512VL, ExtensionAVX512BW)
+
+	assert.NotEqual(t, 0, len(both))
+	assert.True(t, len(both) < len(vl))
+	assert.True(t, len(both) < len(bw))
+	for _, insn := range both {
+		assert.True(t, insn.Extensions.Has(ExtensionAVX512VL))
+		assert.True(t, insn.Extensions.Has(ExtensionAVX512BW))
+	}
+
+	base := db.FindByExtension(ExtensionBase)
+	assert.NotEqual(t, 0, len(base))
+	for _, insn := range base {
+		assert.True(t, insn.Extensions.IsEmpty())
+	}
+	assert.Equal(t, base, db.FindByExtensions(ExtensionBase))
+	assert.Equal(t, 0, len(db.FindByExtensions(ExtensionBase, ExtensionSSE)))
+}
+
+func TestFindByCPULevel(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+
+	i8086 := db.FindByCPULevel(CPULevel8086)
+	p6 := db.FindByCPULevel(CPULevelP6)
+
+	assert.NotEqual(t, 0, len(i8086))
+	assert.True(t, len(i8086) < len(p6))
+	for _, insn := range p6 {
+		assert.True(t, insn.CPULevel <= CPULevelP6)
+		assert.NotEqual(t, CPULevelNone, insn.CPULevel)
+	}
+}
+
+func TestFindByAttr(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+
+	priv := db.FindByAttr(AttrPRIV)
+	assert.NotEqual(t, 0, len(priv))
+	for _, insn := range priv {
+		assert.True(t, strings.Contains(insn.Flags, "PRIV"))
+	}
+
+	hle := db.FindByAttr(AttrHLE)
+	assert.NotEqual(t, 0, len(hle))
+	for _, insn := range hle {
+		assert.Equal(t, "XTEST", insn.Name)
+	}
+}
+
+func TestExtensionCatalog(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+
+	for _, insn := range db.Instructions {
+		// "Special instructions" are assembler directives (RESB).
+		if insn.Group.Title == "Conventional instructions" ||
+			strings.HasPrefix(insn.Group.Title, "Special instructions") {
+			continue
+		}
+		assert.False(t, insn.Extensions.IsEmpty(), "%s %s in '%s' is Base",
+			insn.Name, strings.Join(insn.Operands, ","), insn.Group)
+	}
+
+	tests := []struct {
+		name      string
+		extension Extension
+	}{
+		{"AESENC", ExtensionAES},
+		{"VAESENC", ExtensionAES},
+		{"PCLMULQDQ", ExtensionCLMUL},
+		{"VPCMOV", ExtensionXOP},
+		{"VFMADDPD", ExtensionFMA4},
+		{"XTEST", ExtensionHLE},
+		{"XTEST", ExtensionRTM},
+		{"XSAVEOPT", ExtensionXSAVE},
+		{"ADCX", ExtensionADX},
+		{"PFNACC", Extension3DNOWEXT},
+		{"KANDW", ExtensionAVX512},
+		{"KANDB", ExtensionAVX512DQ},
+		{"KANDQ", ExtensionAVX512BW},
+	}
+
+	for _, test := range tests {
+		insns := db.Instructions.Where(func(insn Instruction) bool {
+			return insn.Name == test.name
+		})
+		assert.NotEqual(t, 0, len(insns), test.name)
+		for _, insn := range insns {
+			assert.True(t, insn.Extensions.Has(test.extension),
+				"%s: %s", test.name, insn.Extensions)
+		}
+	}
+}
+
+func TestFindByMode(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+
+	modes := func(name string, operands string) []Modes {
+		var m []Modes
+		for _, insn := range db.Instructions {
+			if insn.Name == name && strings.Join(insn.Operands, ",") == operands {
+				m = append(m, insn.Modes)
+			}
+		}
+		return m
+	}
+
+	assert.Equal(t, []Modes{ModesAll}, modes("ADD", "reg_eax,imm"))
+	assert.Equal(t, []Modes{Mode64}, modes("ADD", "reg_rax,imm"))
+	assert.Equal(t, []Modes{Mode64, Mode64}, modes("ADD", "reg64,reg64"))
+	assert.Equal(t, []Modes{ModesAll, Mode16 | Mode32}, modes("ADD", "rm8,imm"))
+	assert.Equal(t, []Modes{Mode16 | Mode32}, modes("AAA", "void"))
+	assert.Equal(t, []Modes{Mode16 | Mode32}, modes("JCXZ", "imm"))
+	assert.Equal(t, []Modes{Mode64}, modes("SWAPGS", "void"))
+	assert.Equal(t, []Modes{Mode64}, modes("CMPXCHG16B", "mem"))
+	assert.Equal(t, []Modes{ModesAll}, modes("PADDB", "mmxreg,mmxrm"))
+	assert.Equal(t, []Modes{Mode64}, modes("JMP", "imm64"))
+	// X64 forms.
+	assert.Equal(t, []Modes{Mode16 | Mode32, Mode64}, modes("RDPID", "reg32"))
+	assert.Equal(t, []Modes{Mode64}, modes("RDPKRU", "void"))
+	assert.Equal(t, []Modes{Mode64}, modes("SKINIT", "void"))
+	assert.Equal(t, []Modes{Mode64, ModesAll}, modes("MFENCE", "void"))
+
+	insns := db.FindByMode(Mode64)
+	assert.NotEqual(t, 0, len(insns))
+	for _, insn := range insns {
+		assert.False(t, insn.Attr.Has(AttrNOLONG))
+	}
+}
+
+func TestGroups(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+
+	groups := db.Groups()
+	assert.NotEqual(t, 0, len(groups))
+	for i, g := range groups {
+		assert.Equal(t, i+1, g.Ordinal)
+	}
+
+	g, err := db.GroupFromString("vmx/svm")
+	assert.Nil(t, err)
+	assert.Equal(t, "VMX/SVM Instructions", g.Title)
+
+	byOrdinal, err := db.GroupFromString(strconv.Itoa(g.Ordinal))
+	assert.Nil(t, err)
+	assert.Equal(t, g, byOrdinal)
+
+	_, err = db.GroupFromString("Intel")
+	assert.NotNil(t, err)
+	_, err = db.GroupFromString("Z80")
+	assert.NotNil(t, err)
+	_, err = db.GroupFromString("0")
+	assert.NotNil(t, err)
+
+	insns := db.FindByGroup(g)
+	assert.NotEqual(t, 0, len(insns))
+	for _, insn := range insns {
+		assert.True(t, insn.Extensions.Has(ExtensionVMX))
 	}
 }
@@ -0,0 +1,606 @@
+// Package decode disassembles 64-bit mode machine code into x86db instruction
+// forms, using the code strings of insns.dat as the only description of the
+// encodings:
+//
+//	insn, err := decode.Decode(db, []byte{0x48, 0x83, 0xc0, 0x01})
+//	fmt.Println(insn) // ADD rax,0x1
+//
+// The operands are the concrete operands of the encode package so decoded
+// instructions can be encoded back. The DB should be opened with
+// LoadOptions.ExpandConditions, condition code templates aren't decoded.
+package decode
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dlespiau/x86db"
+	"github.com/dlespiau/x86db/encode"
+)
+
+// MaxLength is the maximum length of an instruction in bytes.
+const MaxLength = 15
+
+// ErrTruncated is returned by Decode when the bytes end before the end of the
+// instruction.
+var ErrTruncated = errors.New("truncated instruction")
+
+// Instruction is a decoded instruction.
+type Instruction struct {
+	// Form is the matched instruction form.
+	Form *x86db.Instruction
+	// Args are the operands, lined up with Form.Args. Relative branch
+	// targets are immediates holding the displacement from the end of the
+	// instruction, see Target.
+	Args []encode.Operand
+	// Prefixes are the legacy prefixes in the order they were found,
+	// mandatory prefixes included.
+	Prefixes []byte
+	// REX is the REX prefix, 0 if none.
+	REX byte
+	// Len is the length of the instruction in bytes.
+	Len int
+	// Addr is the address of the instruction, 0 unless decoded by a
+	// Decoder.
+	Addr uint64
+}
+
+// String implements the stringer interface for Instruction.
+func (insn *Instruction) String() string {
+	if len(insn.Args) == 0 {
+		return insn.Form.Name
+	}
+	args := make([]string, len(insn.Args))
+	for i, arg := range insn.Args {
+		args[i] = arg.String()
+	}
+	return insn.Form.Name + " " + strings.Join(args, ",")
+}
+
+// Target returns the address targeted by a relative branch.
+func (insn *Instruction) Target() (uint64, bool) {
+	for i := range insn.Form.Args {
+		if insn.Form.Args[i].Kind != x86db.OperandRelative {
+			continue
+		}
+		if disp, ok := insn.Args[i].(encode.Immediate); ok {
+			return insn.Addr + uint64(insn.Len) + uint64(disp), true
+		}
+	}
+	return 0, false
+}
+
+// decoder holds the state of the decoding of one instruction form.
+type decoder struct {
+	form *x86db.Instruction
+	e    *x86db.Encoding
+	code []byte
+	insn *Instruction
+
+	opsize, adsize bool
+	// segment is the last segment override prefix, 0 if none.
+	segment byte
+
+	// r, x, b, r2 and v2 are the REX, VEX or EVEX register extension bits,
+	// not inverted. r2 and v2 are EVEX.R' and EVEX.V'.
+	w, r, x, b, r2, v2 int
+	vvvv               int
+	// mask, zeroing, evexB and ll are the EVEX aaa, z, b and L'L fields.
+	mask    int
+	zeroing bool
+	evexB   bool
+	ll      int
+
+	// i is the position of the next byte to decode.
+	i int
+	// modRM is the ModRM byte.
+	modRM byte
+}
+
+var segmentRegisters = map[byte]string{
+	0x26: "es", 0x2e: "cs", 0x36: "ss", 0x3e: "ds", 0x64: "fs", 0x65: "gs",
+}
+
+// Decode decodes the instruction at the start of code. ErrTruncated is
+// returned when code holds the start of an instruction only.
+func Decode(db *x86db.DB, code []byte) (*Instruction, error) {
+	if len(code) > MaxLength {
+		code = code[:MaxLength]
+	}
+	if len(code) == 0 {
+		return nil, ErrTruncated
+	}
+
+	insn, err := decode(db, code)
+	if err == nil {
+		return insn, nil
+	}
+	// The instruction is truncated if it decodes once padded.
+	if len(code) < MaxLength {
+		var padded [MaxLength]byte
+		copy(padded[:], code)
+		if insn, perr := decode(db, padded[:]); perr == nil && insn.Len > len(code) {
+			return nil, ErrTruncated
+		}
+	}
+	return nil, err
+}
+
+// decode decodes the instruction at the start of code with the first form
+// matching it.
+func decode(db *x86db.DB, code []byte) (*Instruction, error) {
+	matches, err := db.MatchBytes(code)
+	if err != nil {
+		return nil, err
+	}
+
+	// Forms are tried from the most specific, the first one whose operands
+	// can be decoded wins. Vendor specific and undocumented forms come
+	// after the others, ND forms last.
+	sort.SliceStable(matches, func(i, j int) bool {
+		pi, pj := priority(matches[i].Instruction), priority(matches[j].Instruction)
+		if pi != pj {
+			return pi < pj
+		}
+		return specificity(matches[i].Instruction) > specificity(matches[j].Instruction)
+	})
+	for _, m := range matches {
+		if m.Instruction.Encoding.Add == x86db.AddCondition {
+			continue
+		}
+		if insn, err := decodeMatch(m, code); err == nil {
+			return insn, nil
+		}
+	}
+
+	n := len(code)
+	if n > 4 {
+		n = 4
+	}
+	return nil, fmt.Errorf("no instruction matches '% x'", code[:n])
+}
+
+// decodeMatch decodes code as the form of the match m.
+func decodeMatch(m x86db.Match, code []byte) (*Instruction, error) {
+	d := &decoder{
+		form: m.Instruction,
+		e:    &m.Instruction.Encoding,
+		code: code[:m.Length],
+		insn: &Instruction{Form: m.Instruction, Len: m.Length},
+	}
+	if err := d.decode(); err != nil {
+		return nil, err
+	}
+	return d.insn, nil
+}
+
+// priority returns the rank of the form when several forms match, lowest
+// first.
+func priority(form *x86db.Instruction) int {
+	switch {
+	case form.Attr.Has(x86db.AttrND):
+		return 2
+	case form.Attr.Has(x86db.AttrCYRIX), form.Attr.Has(x86db.AttrUNDOC):
+		return 1
+	}
+	return 0
+}
+
+// specificity returns how many of the prefixes a form requires, eg. CRC32 and
+// its f2 prefix over MOVBE, which ignores it.
+func specificity(form *x86db.Instruction) int {
+	n := 0
+	if form.Encoding.Prefix != 0 {
+		n++
+	}
+	if form.Encoding.OperandSize != x86db.SizeNone {
+		n++
+	}
+	return n
+}
+
+func bit(b byte, n uint) int {
+	return int(b>>n) & 1
+}
+
+func (d *decoder) decode() error {
+	if err := d.decodePrefixes(); err != nil {
+		return err
+	}
+
+	d.i += len(d.e.Opcode)
+	if d.e.ModRM != nil {
+		d.modRM = d.code[d.i]
+	}
+
+	args := make([]encode.Operand, len(d.form.Args))
+	for i := range d.form.Args {
+		arg, err := d.operand(i)
+		if err != nil {
+			return err
+		}
+		args[i] = arg
+	}
+	if err := d.decorate(args); err != nil {
+		return err
+	}
+	d.insn.Args = args
+
+	// The form accepts the operands as decoded: fixed registers, operand
+	// sizes and immediate ranges are right.
+	specs := make([]x86db.OperandSpec, len(args))
+	for i, arg := range args {
+		specs[i] = arg.Spec()
+		specs[i].Modifier = d.form.Args[i].Modifier
+	}
+	if !d.form.Accepts(specs...) {
+		return fmt.Errorf("operands not accepted by the form")
+	}
+
+	return nil
+}
+
+// decodePrefixes decodes the legacy prefixes, the REX, VEX, XOP or EVEX
+// prefix and the escape bytes.
+func (d *decoder) decodePrefixes() error {
+	code := d.code
+	if d.e.Flags.Has(x86db.EncodingWait) {
+		d.i++
+		if len(d.e.Opcode) == 0 {
+			// FWAIT is the WAIT byte alone.
+			return nil
+		}
+	}
+
+prefixes:
+	for ; d.i < len(code); d.i++ {
+		c := code[d.i]
+		switch c {
+		case 0x66:
+			d.opsize = true
+		case 0x67:
+			d.adsize = true
+		case 0x26, 0x2e, 0x36, 0x3e, 0x64, 0x65:
+			d.segment = c
+		case 0xf0, 0xf2, 0xf3:
+		default:
+			break prefixes
+		}
+		d.insn.Prefixes = append(d.insn.Prefixes, c)
+	}
+
+	if c := code[d.i]; c&0xf0 == 0x40 {
+		d.insn.REX = c
+		d.w, d.r, d.x, d.b = bit(c, 3), bit(c, 2), bit(c, 1), bit(c, 0)
+		d.i++
+	}
+
+	c := code[d.i:]
+	switch d.e.Space {
+	case x86db.EncodingLegacy:
+		switch d.e.Map {
+		case x86db.Map0F:
+			d.i++
+		case x86db.Map0F38, x86db.Map0F3A:
+			d.i += 2
+		}
+		return nil
+	case x86db.EncodingVEX, x86db.EncodingXOP:
+		if d.insn.REX != 0 {
+			return fmt.Errorf("REX prefix before %s", d.e.Space)
+		}
+		d.r = bit(c[1], 7) ^ 1
+		if c[0] == 0xc5 {
+			d.vvvv = int(^c[1]>>3) & 0xf
+			d.i += 2
+			break
+		}
+		d.x, d.b = bit(c[1], 6)^1, bit(c[1], 5)^1
+		d.w = bit(c[2], 7)
+		d.vvvv = int(^c[2]>>3) & 0xf
+		d.i += 3
+	case x86db.EncodingEVEX:
+		if d.insn.REX != 0 {
+			return fmt.Errorf("REX prefix before %s", d.e.Space)
+		}
+		d.r, d.x, d.b = bit(c[1], 7)^1, bit(c[1], 6)^1, bit(c[1], 5)^1
+		d.r2 = bit(c[1], 4) ^ 1
+		d.w = bit(c[2], 7)
+		d.vvvv = int(^c[2]>>3) & 0xf
+		d.zeroing = c[3]&0x80 != 0
+		d.ll = int(c[3]>>5) & 3
+		d.evexB = c[3]&0x10 != 0
+		d.v2 = bit(c[3], 3) ^ 1
+		d.mask = int(c[3] & 7)
+		d.i += 4
+	}
+
+	// vvvv is 1111b when unused, V' is also used by VSIB.
+	if d.e.VVVVOperand < 0 && (d.vvvv != 0 ||
+		d.v2 != 0 && d.e.VSIB == x86db.RegisterClassNone) {
+		return fmt.Errorf("vvvv not used by the form")
+	}
+	return nil
+}
+
+// operandSize returns the operand size of the instruction.
+func (d *decoder) operandSize() int {
+	switch d.e.OperandSize {
+	case x86db.Size16:
+		return 16
+	case x86db.Size32:
+		return 32
+	case x86db.Size64, x86db.Size64NoW:
+		return 64
+	}
+	if d.w != 0 && d.e.Space == x86db.EncodingLegacy {
+		return 64
+	}
+	if d.opsize && d.e.Prefix != 0x66 {
+		return 16
+	}
+	if d.e.OperandSize == x86db.SizeDefault {
+		return 64
+	}
+	return 32
+}
+
+// addressSize returns the address size of the instruction.
+func (d *decoder) addressSize() int {
+	if d.adsize {
+		return 32
+	}
+	return 64
+}
+
+// register returns the register number num of the register file of the
+// operand arg.
+func (d *decoder) register(arg *x86db.Operand, num int) (encode.Register, error) {
+	switch arg.Class {
+	case x86db.RegisterClassGP:
+		size := arg.Size
+		if size == 0 {
+			size = d.operandSize()
+		}
+		// VEX, XOP and EVEX imply a REX prefix: 4 to 7 are spl to dil.
+		rex := d.insn.REX != 0 || d.e.Space != x86db.EncodingLegacy
+		return encode.RegisterFromNumber(arg.Class, size, num, rex)
+	case x86db.RegisterClassSegment, x86db.RegisterClassFPU, x86db.RegisterClassMMX,
+		x86db.RegisterClassMask, x86db.RegisterClassBound:
+		// The extension bits are ignored for the small register files.
+		num &= 7
+	}
+	return encode.RegisterFromNumber(arg.Class, 0, num, false)
+}
+
+// operand decodes the operand i of the form.
+func (d *decoder) operand(i int) (encode.Operand, error) {
+	arg := &d.form.Args[i]
+	e := d.e
+	m := e.ModRM
+
+	switch {
+	case m != nil && m.Reg == i:
+		return d.register(arg, int(d.modRM>>3&7)|d.r<<3|d.r2<<4)
+	case m != nil && m.RM == i:
+		if d.modRM>>6 == 3 {
+			// EVEX.X extends the r/m register to 32 registers.
+			num := int(d.modRM&7) | d.b<<3
+			if e.Space == x86db.EncodingEVEX {
+				num |= d.x << 4
+			}
+			return d.register(arg, num)
+		}
+		return d.memory(arg)
+	case m != nil && m.Index == i:
+		// MIB: the index of the memory operand is a separate operand.
+		if d.modRM>>6 == 3 || d.modRM&7 != 4 {
+			return nil, fmt.Errorf("missing index register")
+		}
+		index := int(d.code[d.modRM1()]>>3&7) | d.x<<3
+		if index == 4 {
+			return nil, fmt.Errorf("missing index register")
+		}
+		return d.register(arg, index)
+	case e.VVVVOperand == i:
+		return d.register(arg, d.vvvv|d.v2<<4)
+	case e.OpcodeOperand == i:
+		opcode := d.code[d.i-1]
+		return d.register(arg, int(opcode&7)|d.b<<3)
+	}
+
+	for n, imm := range e.Immediates {
+		if imm.Operand == i {
+			return d.immediate(arg, n)
+		}
+	}
+
+	if arg.Fixed != "" {
+		return encode.MustRegister(arg.Fixed), nil
+	}
+	if arg.Range == x86db.ImmediateUnity {
+		return encode.Immediate(1), nil
+	}
+	return nil, fmt.Errorf("operand %d not encoded", i)
+}
+
+// modRM1 returns the position of the byte following the ModRM byte.
+func (d *decoder) modRM1() int {
+	return d.i + 1
+}
+
+// memory decodes the memory operand held by the ModRM byte.
+func (d *decoder) memory(arg *x86db.Operand) (encode.Operand, error) {
+	e := d.e
+	mod, rm := d.modRM>>6, d.modRM&7
+	i := d.modRM1()
+
+	mem := encode.Memory{Size: arg.Size}
+	if d.segment != 0 {
+		mem.Segment = encode.MustRegister(segmentRegisters[d.segment])
+	}
+	if d.evexB {
+		mem.Size = 0
+		mem.Broadcast = arg.Broadcast
+	}
+
+	if d.adsize {
+		mem.AddressSize = 32
+	}
+	gp := func(num int) encode.Register {
+		r, _ := encode.RegisterFromNumber(x86db.RegisterClassGP, d.addressSize(), num, true)
+		return r
+	}
+
+	if mod == 0 && rm == 5 {
+		if d.adsize {
+			return nil, fmt.Errorf("eip-relative addressing not supported")
+		}
+		mem.RIP = true
+		mem.Disp = int64(int32(binary.LittleEndian.Uint32(d.code[i:])))
+		return mem, nil
+	}
+
+	base := int(rm)
+	if rm == 4 {
+		sib := d.code[i]
+		i++
+		base = int(sib & 7)
+		mem.Scale = 1 << (sib >> 6)
+		index := int(sib>>3&7) | d.x<<3
+		switch {
+		case e.VSIB != x86db.RegisterClassNone:
+			index |= d.v2 << 4
+			mem.Index, _ = encode.RegisterFromNumber(e.VSIB, 0, index, false)
+		case index != 4 && e.ModRM.Index < 0:
+			mem.Index = gp(index)
+		}
+		if mem.Index.IsNone() && e.ModRM.Index < 0 {
+			mem.Scale = 0
+		}
+		if base == 5 && mod == 0 {
+			mem.Disp = int64(int32(binary.LittleEndian.Uint32(d.code[i:])))
+			return mem, nil
+		}
+	}
+	mem.Base = gp(base | d.b<<3)
+
+	switch mod {
+	case 1:
+		mem.Disp = int64(int8(d.code[i])) * int64(e.Disp8N(d.evexB))
+	case 2:
+		mem.Disp = int64(int32(binary.LittleEndian.Uint32(d.code[i:])))
+	}
+
+	return mem, nil
+}
+
+// immediateStart returns the position of the first immediate.
+func (d *decoder) immediateStart() int {
+	i := d.i
+	if d.e.ModRM != nil {
+		n := 1
+		if mod, rm := d.modRM>>6, d.modRM&7; mod != 3 {
+			base := rm
+			if rm == 4 {
+				base = d.code[i+1] & 7
+				n++
+			}
+			switch {
+			case mod == 1:
+				n++
+			case mod == 2, mod == 0 && base == 5:
+				n += 4
+			}
+		}
+		i += n + len(d.e.Suffix)
+	}
+	return i
+}
+
+// immediate decodes the immediate n of the encoding, the operand arg.
+func (d *decoder) immediate(arg *x86db.Operand, n int) (encode.Operand, error) {
+	e := d.e
+	operandSize, addressSize := d.operandSize(), d.addressSize()
+
+	i := d.immediateStart()
+	for k, imm := range e.Immediates[:n] {
+		i += imm.Type.Length(operandSize, addressSize)
+		if k == 0 && e.ModRM == nil {
+			i += len(e.Suffix)
+		}
+	}
+
+	imm := e.Immediates[n]
+	code := d.code[i:]
+	var v int64
+	switch size := imm.Type.Length(operandSize, addressSize); size {
+	case 1:
+		v = int64(code[0])
+	case 2:
+		v = int64(binary.LittleEndian.Uint16(code))
+	case 4:
+		v = int64(binary.LittleEndian.Uint32(code))
+	case 8:
+		v = int64(binary.LittleEndian.Uint64(code))
+	}
+
+	switch imm.Type {
+	case x86db.ImmSByte, x86db.ImmRel8:
+		v = int64(int8(v))
+	case x86db.ImmSDword:
+		v = int64(int32(v))
+	case x86db.ImmRel:
+		if operandSize == 16 {
+			v = int64(int16(v))
+		} else {
+			v = int64(int32(v))
+		}
+	case x86db.ImmIs4:
+		return d.register(arg, int(v>>4))
+	case x86db.ImmWordDwordQword:
+		mem := encode.Memory{Disp: v}
+		if d.adsize {
+			mem.AddressSize = 32
+		}
+		return mem, nil
+	}
+
+	return encode.Immediate(v), nil
+}
+
+// decorate applies the EVEX opmask, zeroing, rounding and SAE decorators to
+// the operands.
+func (d *decoder) decorate(args []encode.Operand) error {
+	masked := false
+	for i := range d.form.Args {
+		arg := &d.form.Args[i]
+
+		if arg.Mask && d.mask != 0 {
+			args[i] = encode.Masked{
+				Operand: args[i],
+				Mask:    encode.MustRegister(fmt.Sprintf("k%d", d.mask)),
+				Zeroing: d.zeroing,
+			}
+			masked = true
+		}
+
+		r, ok := args[i].(encode.Register)
+		if !ok || !d.evexB || !arg.Rounding && !arg.SAE {
+			continue
+		}
+		rounding := encode.SAE
+		if arg.Rounding {
+			rounding = encode.Rounding(d.ll)
+		}
+		args[i] = encode.Rounded{Register: r, Rounding: rounding}
+	}
+
+	if d.mask != 0 && !masked || d.zeroing && !masked {
+		return fmt.Errorf("opmask not allowed by the form")
+	}
+	return nil
+}
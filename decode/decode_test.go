@@ -0,0 +1,160 @@
+package decode
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/dlespiau/x86db"
+	"github.com/dlespiau/x86db/encode"
+	"github.com/stretchr/testify/assert"
+)
+
+func openDB(t *testing.T) *x86db.DB {
+	db := x86db.NewDBWithOptions("../data/insns.dat",
+		x86db.LoadOptions{ExpandConditions: true})
+	assert.Nil(t, db.Open())
+	return db
+}
+
+func TestDecode(t *testing.T) {
+	db := openDB(t)
+
+	tests := []struct {
+		code     string
+		valid    bool
+		insn     string
+		prefixes string
+		length   int
+	}{
+		{"4883c001", true, "ADD rax,0x1", "", 4},
+		{"4101c9", true, "ADD r9d,ecx", "", 3},
+		{"4a8b84e500010000", true, "MOV rax,[rbp+r12*8+0x100]", "", 8},
+		{"488b0510000000", true, "MOV rax,[rel 0x10]", "", 7},
+		{"8b042510000000", true, "MOV eax,[0x10]", "", 7},
+		{"67488b03", true, "MOV rax,[ebx]", "67", 4},
+		{"64488b042528000000", true, "MOV rax,[fs:0x28]", "64", 9},
+		{"48a11000000000000000", true, "MOV rax,[0x10]", "", 10},
+		{"f0480fb111", true, "CMPXCHG [rcx],rdx", "f0", 5},
+		{"f3a4", true, "MOVSB", "f3", 2},
+		{"0f8500010000", true, "JNE 0x100", "", 6},
+		{"e8fbffffff", true, "CALL -0x5", "", 5},
+		{"9bdfe0", true, "FSTSW ax", "", 3},
+		{"9b90", true, "FWAIT", "", 1},
+		{"67a110000000", true, "MOV eax,[0x10]", "67", 6},
+		// Mandatory prefix and REX.W forms.
+		{"f20f38f0c1", true, "CRC32 eax,cl", "f2", 5},
+		{"66480f7ec1", true, "MOVQ rcx,xmm0", "66", 5},
+		// VEX and XOP.
+		{"c4e2edb8cb", true, "VFMADD231PD ymm1,ymm2,ymm3", "", 5},
+		{"c4a269900c90", true, "VPGATHERDD xmm1,dword [rax+xmm10*4],xmm2", "", 6},
+		{"8fe828a2cbc0", true, "VPCMOV xmm1,xmm10,xmm3,xmm12", "", 6},
+		// EVEX.
+		{"62f16cc958cb", true, "VADDPS zmm1{k1}{z},zmm2,zmm3", "", 6},
+		{"62f16c58584810", true, "VADDPS zmm1,zmm2,dword bcst [rax+0x40]", "", 7},
+		{"62f16c3858cb", true, "VADDPS zmm1,zmm2,zmm3{rd-sae}", "", 6},
+		{"62f17c182eca", true, "VUCOMISS xmm1,xmm2{sae}", "", 6},
+		{"62f37d0814c7ff", true, "VPEXTRB dil,xmm0,0xff", "", 7},
+		{"62c14c40584901", true, "VADDPS zmm17,zmm22,zword [r9+0x40]", "", 7},
+		{"62f27d41904ca040", true, "VPGATHERDD zmm1{k1},dword [rax+zmm20*4+0x100]", "",
+			8},
+		// Truncated.
+		{"4883c0", false, "", "", 0},
+		{"0f1f4400", false, "", "", 0},
+		{"", false, "", "", 0},
+		// EVEX.b set on a form without broadcast.
+		{"62f17d1810c1", false, "", "", 0},
+	}
+
+	for _, test := range tests {
+		code, _ := hex.DecodeString(test.code)
+		insn, err := Decode(db, code)
+		if !test.valid {
+			assert.NotNil(t, err, test.code)
+			continue
+		}
+		if !assert.Nil(t, err, test.code) {
+			continue
+		}
+		assert.Equal(t, test.insn, insn.String(), test.code)
+		assert.Equal(t, test.prefixes, hex.EncodeToString(insn.Prefixes), test.code)
+		assert.Equal(t, test.length, insn.Len, test.code)
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	db := openDB(t)
+
+	tests := []struct {
+		code      string
+		truncated bool
+	}{
+		{"", true},
+		{"4883c0", true},
+		{"0f1f4400", true},
+		{"e8fbff", true},
+		// PUSH es and EVEX.b set on a form without broadcast.
+		{"06", false},
+		{"62f17d1810c1", false},
+	}
+
+	for _, test := range tests {
+		code, _ := hex.DecodeString(test.code)
+		_, err := Decode(db, code)
+		if !assert.NotNil(t, err, test.code) {
+			continue
+		}
+		assert.Equal(t, test.truncated, err == ErrTruncated, test.code)
+	}
+}
+
+func TestDecodeEncode(t *testing.T) {
+	db := openDB(t)
+
+	// The address size survives the decoding.
+	for _, code := range []string{"67a110000000", "678b042510000000", "67488b03"} {
+		b, _ := hex.DecodeString(code)
+		insn, err := Decode(db, b)
+		if !assert.Nil(t, err, code) {
+			continue
+		}
+		encoded, err := encode.Encode(insn.Form, insn.Args...)
+		assert.Nil(t, err, code)
+		assert.Equal(t, code, hex.EncodeToString(encoded))
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	db := openDB(t)
+
+	// add rax,1; jne -6; bad byte; truncated add
+	code, _ := hex.DecodeString("4883c00175fa" + "06" + "4883c0")
+	d := NewDecoder(db, bytes.NewReader(code), 0x1000)
+
+	insn, err := d.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "ADD rax,0x1", insn.String())
+	assert.Equal(t, uint64(0x1000), insn.Addr)
+
+	insn, err = d.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, "JNE -0x6", insn.String())
+	target, ok := insn.Target()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0x1000), target)
+
+	// PUSH es isn't valid in 64-bit mode, decoding resumes after it.
+	_, err = d.Next()
+	assert.NotNil(t, err)
+	assert.NotEqual(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, uint64(0x1007), d.Addr())
+
+	_, err = d.Next()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	assert.Nil(t, d.Seek(0x1000+uint64(len(code))))
+	_, err = d.Next()
+	assert.Equal(t, io.EOF, err)
+	assert.NotNil(t, d.Seek(0))
+}
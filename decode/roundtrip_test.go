@@ -0,0 +1,412 @@
+package decode
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dlespiau/x86db"
+	"github.com/dlespiau/x86db/encode"
+	"github.com/stretchr/testify/assert"
+)
+
+// variant picks the concrete operands of a round-trip case.
+type variant struct {
+	name string
+	// regs are the register numbers of the operands, by operand index.
+	// The EVEX forms use evexRegs for the vector registers.
+	regs, evexRegs []int
+	// mem returns the memory operand of a form, index being a vector
+	// register for VSIB memory operands.
+	mem func(index encode.Register) encode.Memory
+	// memory selects memory operands for the reg-or-mem operands,
+	// registers otherwise.
+	memory bool
+	// max selects the largest immediates, the smallest otherwise.
+	max bool
+	// decorate applies an opmask, zeroing-masking and embedded broadcast
+	// to the EVEX forms allowing them.
+	decorate bool
+	// mask is the opmask register number of the masked forms.
+	mask int
+}
+
+var variants = []variant{
+	{
+		name:     "low",
+		regs:     []int{0, 1, 2, 3, 1},
+		evexRegs: []int{0, 1, 2, 3, 1},
+		mem: func(index encode.Register) encode.Memory {
+			if index.IsNone() {
+				index = encode.MustRegister("rsi")
+			}
+			return encode.Memory{Base: encode.MustRegister("rbx"), Index: index,
+				Scale: 4, Disp: 0x10}
+		},
+		mask: 1,
+	},
+	{
+		name:     "high",
+		regs:     []int{9, 14, 15, 8, 10},
+		evexRegs: []int{17, 30, 31, 16, 9},
+		mem: func(index encode.Register) encode.Memory {
+			if index.IsNone() {
+				index = encode.MustRegister("r15")
+			}
+			return encode.Memory{Base: encode.MustRegister("r12"), Index: index,
+				Scale: 8, Disp: -0x80}
+		},
+		memory:   true,
+		max:      true,
+		decorate: true,
+		mask:     7,
+	},
+	{
+		name:     "rip",
+		regs:     []int{8, 0, 15, 1, 2},
+		evexRegs: []int{31, 0, 15, 24, 7},
+		mem: func(index encode.Register) encode.Memory {
+			if !index.IsNone() {
+				return encode.Memory{Base: encode.MustRegister("r13"), Index: index,
+					Disp: 0x7fffffff}
+			}
+			return encode.RIPRelative(-0x80000000, 0)
+		},
+		memory: true,
+		mask:   3,
+	},
+	{
+		name:     "nobase",
+		regs:     []int{7, 13, 4, 12, 6},
+		evexRegs: []int{7, 29, 20, 12, 6},
+		mem: func(index encode.Register) encode.Memory {
+			if index.IsNone() {
+				index = encode.MustRegister("r13")
+			}
+			return encode.Memory{Index: index, Scale: 2, Disp: 0x12345678}
+		},
+		memory:   true,
+		max:      true,
+		decorate: true,
+		mask:     5,
+	},
+}
+
+// immediate returns the smallest or the largest value of an immediate of the
+// form e, as the decoder returns it.
+func immediate(e *x86db.Encoding, t x86db.ImmediateType, max bool) encode.Immediate {
+	if e.OperandSize == x86db.Size16 {
+		switch t {
+		case x86db.ImmWordDword:
+			t = x86db.ImmWord
+		case x86db.ImmRel:
+			if max {
+				return 1<<15 - 1
+			}
+			return -1 << 15
+		}
+	}
+
+	var min, maxv int64
+	switch t {
+	case x86db.ImmSByte, x86db.ImmRel8:
+		min, maxv = -1<<7, 1<<7-1
+	case x86db.ImmByte, x86db.ImmUByte:
+		min, maxv = 0, 1<<8-1
+	case x86db.ImmWord, x86db.ImmSeg:
+		min, maxv = 0, 1<<16-1
+	case x86db.ImmDword, x86db.ImmWordDword:
+		min, maxv = 0, 1<<32-1
+	case x86db.ImmSDword, x86db.ImmRel:
+		min, maxv = -1<<31, 1<<31-1
+	case x86db.ImmQword:
+		min, maxv = -1<<63, 1<<63-1
+	}
+	if max {
+		return encode.Immediate(maxv)
+	}
+	return encode.Immediate(min)
+}
+
+// operandSize returns the size of the unsized general purpose registers of a
+// form.
+func operandSize(e *x86db.Encoding) int {
+	switch e.OperandSize {
+	case x86db.Size16:
+		return 16
+	case x86db.Size32, x86db.SizeNone:
+		return 32
+	}
+	return 64
+}
+
+// register returns the register number num of the register file of arg.
+func register(insn *x86db.Instruction, arg *x86db.Operand, num int) (encode.Register, error) {
+	switch arg.Class {
+	case x86db.RegisterClassGP:
+		size := arg.Size
+		if size == 0 {
+			size = operandSize(&insn.Encoding)
+		}
+		if arg.NotAccumulator && num == 0 {
+			num = 1
+		}
+		return encode.RegisterFromNumber(arg.Class, size, num&15, true)
+	case x86db.RegisterClassSegment:
+		num %= 6
+	case x86db.RegisterClassControl:
+		num = []int{0, 2, 3, 4, 8}[num%5]
+	case x86db.RegisterClassFPU, x86db.RegisterClassMMX, x86db.RegisterClassMask,
+		x86db.RegisterClassDebug:
+		num &= 7
+	case x86db.RegisterClassBound:
+		num &= 3
+	case x86db.RegisterClassXMM, x86db.RegisterClassYMM, x86db.RegisterClassZMM:
+		if insn.Encoding.Space != x86db.EncodingEVEX {
+			num &= 15
+		}
+	}
+	return encode.RegisterFromNumber(arg.Class, 0, num, false)
+}
+
+// immediateTypes returns the type of the immediate of each operand.
+func immediateTypes(insn *x86db.Instruction) map[int]x86db.ImmediateType {
+	types := make(map[int]x86db.ImmediateType)
+	for _, imm := range insn.Encoding.Immediates {
+		if imm.Operand >= 0 {
+			types[imm.Operand] = imm.Type
+		}
+	}
+	return types
+}
+
+// roundTripArgs returns the operands of insn for the variant v.
+func roundTripArgs(insn *x86db.Instruction, v *variant) ([]encode.Operand, error) {
+	e := &insn.Encoding
+	regs := v.regs
+	if e.Space == x86db.EncodingEVEX {
+		regs = v.evexRegs
+	}
+	immTypes := immediateTypes(insn)
+
+	var args []encode.Operand
+	for i := range insn.Args {
+		arg := &insn.Args[i]
+		num := regs[i%len(regs)]
+
+		var op encode.Operand
+		switch {
+		case arg.Fixed != "":
+			op = encode.MustRegister(arg.Fixed)
+		case immTypes[i] == x86db.ImmIs4:
+			r, err := register(insn, arg, num&15)
+			if err != nil {
+				return nil, err
+			}
+			op = r
+		case arg.Kind == x86db.OperandImmediate || arg.Kind == x86db.OperandRelative:
+			switch {
+			case arg.Range == x86db.ImmediateUnity:
+				op = encode.Immediate(1)
+			case immTypes[i] == x86db.ImmWordDwordQword:
+				op = encode.Memory{Disp: 0x10}
+				if v.max {
+					op = encode.Memory{Disp: -1}
+				}
+			default:
+				op = immediate(e, immTypes[i], v.max)
+			}
+		case arg.Offset:
+			op = encode.Memory{Disp: 0x10, Size: arg.Size}
+			if v.max {
+				op = encode.Memory{Disp: -1, Size: arg.Size}
+			}
+		case arg.Kind == x86db.OperandRegister ||
+			arg.Kind == x86db.OperandRegMem && e.ModRM != nil && !v.memory:
+			if e.ModRM != nil && e.ModRM.Index == i {
+				// The MIB index can't be rsp.
+				if num &= 15; num == 4 {
+					num = 12
+				}
+			}
+			r, err := register(insn, arg, num)
+			if err != nil {
+				return nil, err
+			}
+			op = r
+		default:
+			var index encode.Register
+			if arg.VSIB != x86db.RegisterClassNone {
+				r, err := encode.RegisterFromNumber(arg.VSIB, 0, num, false)
+				if err != nil {
+					return nil, err
+				}
+				index = r
+			}
+			m := v.mem(index)
+			if e.ModRM != nil && e.ModRM.Index >= 0 {
+				// MIB: the index is a separate operand.
+				m = encode.Memory{Base: encode.MustRegister("rbx"), Disp: 0x10}
+			}
+			m.Size = arg.Size
+			if v.decorate && arg.Broadcast != 0 {
+				m.Size, m.Broadcast = 0, arg.Broadcast
+			}
+			op = m
+		}
+
+		// The EVEX gathers and scatters can't use k0.
+		if arg.Mask && (v.decorate || hasVSIB(insn)) {
+			mask := encode.MustRegister(fmt.Sprintf("k%d", v.mask))
+			op = encode.Masked{Operand: op, Mask: mask,
+				Zeroing: v.decorate && arg.Zeroing}
+		}
+		args = append(args, op)
+	}
+	return args, nil
+}
+
+// hasVSIB returns whether insn has a VSIB memory operand.
+func hasVSIB(insn *x86db.Instruction) bool {
+	for i := range insn.Args {
+		if insn.Args[i].VSIB != x86db.RegisterClassNone {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeAs decodes code as the form insn.
+func decodeAs(db *x86db.DB, insn *x86db.Instruction, code []byte) (*Instruction, error) {
+	matches, err := db.MatchBytes(code)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range matches {
+		if m.Instruction == insn {
+			return decodeMatch(m, code)
+		}
+	}
+	return nil, fmt.Errorf("the bytes don't match the form")
+}
+
+// sourceLines returns the lines of an insns.dat file.
+func sourceLines(t *testing.T, file string) []string {
+	f, err := os.Open(file)
+	if !assert.Nil(t, err) {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	return lines
+}
+
+// roundTripSkip lists the forms that can't round-trip because of insns.dat
+// itself, by name and operands.
+var roundTripSkip = map[string]string{
+	// Bare escape bytes.
+	"RDM void":   "decodes as the 0f 3a escape",
+	"SMINT void": "decodes as the 0f 38 escape",
+	// ND forms shadowed by the others.
+	"PUSH sbyteword16": "encodes as PUSH imm16",
+	"UMOV mem,reg16":   "np conflicts with o16",
+	"UMOV reg16,reg16": "np conflicts with o16",
+	"UMOV reg16,mem":   "np conflicts with o16",
+	"WRSHR rm32":       "0f 37 is GETSEC",
+}
+
+// skipRoundTrip returns why insn is a form that can't round-trip, "" if it
+// can.
+func skipRoundTrip(insn *x86db.Instruction) string {
+	e := &insn.Encoding
+	switch {
+	case !insn.Modes.Has(x86db.Mode64):
+		return "not valid in 64-bit mode"
+	case len(e.Opcode) == 0 && !e.Flags.Has(x86db.EncodingWait):
+		return "pseudo-instruction"
+	}
+	for _, imm := range e.Immediates {
+		if imm.Type == x86db.ImmJlen {
+			return "jcc over jmp, two instructions"
+		}
+	}
+	return roundTripSkip[insn.Name+" "+strings.Join(insn.Operands, ",")]
+}
+
+func TestRoundTrip(t *testing.T) {
+	const file = "../data/insns.dat"
+	db := x86db.NewDBWithOptions(file, x86db.LoadOptions{ExpandConditions: true})
+	assert.Nil(t, db.Open())
+	lines := sourceLines(t, file)
+
+	for i := range db.Instructions {
+		insn := &db.Instructions[i]
+		if reason := skipRoundTrip(insn); reason != "" {
+			t.Logf("%s:%d: skipped: %s\n\t%s", file, insn.Line, reason,
+				lines[insn.Line-1])
+			continue
+		}
+
+		fail := func(v *variant, format string, args ...interface{}) {
+			t.Errorf("%s:%d: %s: %s\n\t%s", file, insn.Line, v.name,
+				fmt.Sprintf(format, args...), lines[insn.Line-1])
+		}
+
+		for j := range variants {
+			v := &variants[j]
+			args, err := roundTripArgs(insn, v)
+			if err != nil {
+				fail(v, "%v", err)
+				continue
+			}
+			code, err := encode.Encode(insn, args...)
+			if err != nil {
+				fail(v, "encode %s: %v", args, err)
+				continue
+			}
+			decoded, err := decodeAs(db, insn, code)
+			if err != nil {
+				fail(v, "decode % x: %v", code, err)
+				continue
+			}
+			if decoded.Len != len(code) {
+				fail(v, "decode % x: length %d", code, decoded.Len)
+				continue
+			}
+			if fmt.Sprint(decoded.Args) != fmt.Sprint(args) {
+				fail(v, "decode % x: %s, want %s", code, decoded.Args, args)
+				continue
+			}
+
+			// Decode may pick another form, an alias that may not need
+			// all the prefixes of code but that decodes the same way once
+			// encoded.
+			decoded, err = Decode(db, code)
+			if err != nil {
+				fail(v, "decode % x: %v", code, err)
+				continue
+			}
+			if decoded.Len != len(code) {
+				fail(v, "decode % x: %s: length %d", code, decoded, decoded.Len)
+				continue
+			}
+			recoded, err := encode.Encode(decoded.Form, decoded.Args...)
+			if err != nil {
+				fail(v, "encode %s: %v", decoded, err)
+				continue
+			}
+			redecoded, err := Decode(db, recoded)
+			if err != nil || redecoded.String() != decoded.String() {
+				fail(v, "%s re-encodes to % x, decoding as %v (%v)", decoded,
+					recoded, redecoded, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package decode
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dlespiau/x86db"
+)
+
+// Decoder decodes a stream of instructions read from an io.ReaderAt.
+type Decoder struct {
+	db   *x86db.DB
+	r    io.ReaderAt
+	base uint64
+	addr uint64
+	buf  [MaxLength]byte
+}
+
+// NewDecoder returns a Decoder reading instructions from r, the byte at offset
+// 0 of r being at address base.
+func NewDecoder(db *x86db.DB, r io.ReaderAt, base uint64) *Decoder {
+	return &Decoder{
+		db:   db,
+		r:    r,
+		base: base,
+		addr: base,
+	}
+}
+
+// Addr returns the address of the next instruction.
+func (d *Decoder) Addr() uint64 {
+	return d.addr
+}
+
+// Seek sets the address of the next instruction.
+func (d *Decoder) Seek(addr uint64) error {
+	if addr < d.base {
+		return fmt.Errorf("address %#x before the start of the stream %#x", addr, d.base)
+	}
+	d.addr = addr
+	return nil
+}
+
+// Next decodes the next instruction. It returns io.EOF at the end of the
+// stream and io.ErrUnexpectedEOF when the last instruction is truncated. When
+// the bytes can't be decoded, the error is returned and decoding resumes at
+// the next byte.
+func (d *Decoder) Next() (*Instruction, error) {
+	n, err := d.r.ReadAt(d.buf[:], int64(d.addr-d.base))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, io.EOF
+	}
+
+	insn, err := Decode(d.db, d.buf[:n])
+	if err == ErrTruncated {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		err = fmt.Errorf("%#x: %v", d.addr, err)
+		d.addr++
+		return nil, err
+	}
+
+	insn.Addr = d.addr
+	d.addr += uint64(insn.Len)
+	return insn, nil
+}
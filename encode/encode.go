@@ -0,0 +1,592 @@
+// Package encode assembles x86db instruction forms into 64-bit mode machine
+// code, driven by the code strings of insns.dat:
+//
+//	insn := db.Lookup("ADD")[0]
+//	code, err := encode.Encode(&insn, encode.MustRegister("rax"), encode.Immediate(1))
+//
+// Assemble also selects the instruction form from a mnemonic.
+package encode
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dlespiau/x86db"
+)
+
+// encoder holds the state of the encoding of one instruction.
+type encoder struct {
+	insn *x86db.Instruction
+	e    *x86db.Encoding
+	args []Operand
+
+	// reg, rm, index and base are the full register numbers of the ModRM
+	// reg field, the r/m register, the memory index and the memory base.
+	// Their high bits are encoded in the REX, VEX or EVEX prefixes.
+	reg, rm, index, base int
+	// modRM holds the ModRM byte, the SIB byte and the displacement.
+	modRM []byte
+	// mem is the memory operand, if any.
+	mem *Memory
+	// disp8N is the disp8*N scaling factor of EVEX encodings, 1 otherwise.
+	disp8N int
+
+	// rex is set when a REX prefix is needed for the byte registers spl,
+	// bpl, sil and dil. noREX is set when ah, ch, dh or bh is used.
+	rex, noREX bool
+	// addrSize is set when the 0x67 prefix is needed.
+	addrSize bool
+
+	// mask is the opmask register number, zeroing is set for {z}. rounding
+	// is the embedded rounding mode, nil if none. broadcast is set for a
+	// {1toN} memory operand.
+	mask      int
+	zeroing   bool
+	rounding  *Rounding
+	broadcast bool
+}
+
+// alignArgs returns the operands lined up with the operands of the form,
+// inserting the omitted '*' operand: a copy of the first operand.
+func alignArgs(insn *x86db.Instruction, args []Operand) []Operand {
+	if len(args) != len(insn.Args)-1 {
+		return args
+	}
+	for i := range insn.Args {
+		if insn.Args[i].OptionalNDS {
+			aligned := make([]Operand, 0, len(insn.Args))
+			aligned = append(aligned, args[:i]...)
+			aligned = append(aligned, args[0])
+			return append(aligned, args[i:]...)
+		}
+	}
+	return args
+}
+
+func operandsString(args []Operand) string {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		strs[i] = arg.String()
+	}
+	return strings.Join(strs, ",")
+}
+
+// Encode returns the machine code of the instruction form insn with the given
+// operands, in 64-bit mode. An operand marked with '*' in the form can be
+// omitted, the first operand is then used in its place.
+func Encode(insn *x86db.Instruction, args ...Operand) ([]byte, error) {
+	if !insn.Modes.Has(x86db.Mode64) {
+		return nil, fmt.Errorf("%s %s: not available in 64-bit mode", insn.Name,
+			strings.Join(insn.Operands, ","))
+	}
+
+	// The form is given, its near, far, short and to modifiers don't have
+	// to be spelled out.
+	aligned := alignArgs(insn, args)
+	specs := make([]x86db.OperandSpec, len(aligned))
+	for i, arg := range aligned {
+		specs[i] = arg.Spec()
+		if i < len(insn.Args) {
+			specs[i].Modifier = insn.Args[i].Modifier
+		}
+	}
+	if !insn.Accepts(specs...) {
+		return nil, fmt.Errorf("%s %s: invalid operands '%s'", insn.Name,
+			strings.Join(insn.Operands, ","), operandsString(args))
+	}
+
+	enc := &encoder{
+		insn: insn,
+		e:    &insn.Encoding,
+		args: append([]Operand(nil), aligned...),
+	}
+	// FWAIT is only its wait prefix.
+	if len(enc.e.Opcode) == 0 && !enc.e.Flags.Has(x86db.EncodingWait) {
+		return nil, fmt.Errorf("%s is a pseudo-instruction", insn.Name)
+	}
+	if enc.e.Add == x86db.AddCondition {
+		return nil, fmt.Errorf("%s is a condition code template, see LoadOptions.ExpandConditions",
+			insn.Name)
+	}
+
+	code, err := enc.encode()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %v", insn.Name, operandsString(args), err)
+	}
+	return code, nil
+}
+
+// Assemble encodes mnemonic with the given operands, selecting the form
+// giving the shortest encoding, see DB.SelectShortest. Relative branch
+// displacements are relative to the end of the selected form.
+func Assemble(db *x86db.DB, mnemonic string, args ...Operand) ([]byte, error) {
+	specs := make([]x86db.OperandSpec, len(args))
+	for i, arg := range args {
+		specs[i] = arg.Spec()
+	}
+
+	c, err := db.SelectShortest(mnemonic, x86db.SelectOptions{Optimize: true}, specs...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pseudo-mnemonics have an implied imm8.
+	if _, imm8, ok := db.Canonical(strings.ToUpper(mnemonic)); ok {
+		args = append(args, Immediate(imm8))
+	}
+
+	return Encode(c.Instruction, args...)
+}
+
+func (enc *encoder) encode() ([]byte, error) {
+	if err := enc.unwrapDecorators(); err != nil {
+		return nil, err
+	}
+	enc.scanRegisters()
+	enc.disp8N = enc.e.Disp8N(enc.broadcast)
+	if enc.e.ModRM != nil {
+		if err := enc.encodeModRM(); err != nil {
+			return nil, err
+		}
+	}
+	if enc.e.AddressSize == x86db.Size32 || enc.moffsAddressSize() == 32 {
+		enc.addrSize = true
+	}
+
+	var code []byte
+	var err error
+	switch enc.e.Space {
+	case x86db.EncodingLegacy:
+		code, err = enc.legacyPrefixes()
+	case x86db.EncodingVEX, x86db.EncodingXOP:
+		code, err = enc.vexPrefix()
+	case x86db.EncodingEVEX:
+		code, err = enc.evexPrefix()
+	default:
+		return nil, fmt.Errorf("%s encoding not supported", enc.e.Space)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	code = append(code, enc.opcode()...)
+	return enc.appendTail(code)
+}
+
+// scanRegisters looks for the byte registers constraining the use of REX.
+func (enc *encoder) scanRegisters() {
+	for _, arg := range enc.args {
+		r, ok := arg.(Register)
+		if !ok {
+			continue
+		}
+		if r.needsREX() {
+			enc.rex = true
+		}
+		if r.isHighByte() {
+			enc.noREX = true
+		}
+	}
+}
+
+// register returns the register operand i.
+func (enc *encoder) register(i int) Register {
+	if r, ok := enc.args[i].(Register); ok {
+		return r
+	}
+	return Register{}
+}
+
+// encodeModRM computes the ModRM byte, SIB byte and displacement.
+func (enc *encoder) encodeModRM() error {
+	m := enc.e.ModRM
+
+	enc.reg = m.Digit
+	if m.Digit < 0 {
+		enc.reg = 0
+		if m.Reg >= 0 {
+			enc.reg = enc.register(m.Reg).Num
+		}
+	}
+
+	if m.RM < 0 {
+		enc.modRM = []byte{0xc0 | byte(enc.reg&7)<<3}
+		return nil
+	}
+
+	switch op := enc.args[m.RM].(type) {
+	case Register:
+		enc.rm = op.Num
+		enc.modRM = []byte{0xc0 | byte(enc.reg&7)<<3 | byte(op.Num&7)}
+		return nil
+	case Memory:
+		if m.Index >= 0 {
+			// MIB: the index is a separate operand.
+			if !op.Index.IsNone() {
+				return fmt.Errorf("memory operand can't have an index")
+			}
+			op.Index = enc.register(m.Index)
+		}
+		enc.mem = &op
+		return enc.encodeMemory(op)
+	}
+
+	return fmt.Errorf("invalid r/m operand '%s'", enc.args[m.RM])
+}
+
+// checkAddressSize checks the address size of the memory operand m against
+// the size of its address registers, 0 if none.
+func checkAddressSize(m Memory, regSize int) error {
+	switch {
+	case m.AddressSize == 0:
+		return nil
+	case m.AddressSize != 32 && m.AddressSize != 64,
+		regSize != 0 && regSize != m.AddressSize:
+		return fmt.Errorf("invalid address size %d in '%s'", m.AddressSize, m)
+	}
+	return nil
+}
+
+var scaleBits = map[int]byte{0: 0, 1: 0, 2: 1, 4: 2, 8: 3}
+
+// disp8 returns the value of the 8-bit displacement of disp, taking the
+// disp8*N scaling into account.
+func (enc *encoder) disp8(disp int64) (int8, bool) {
+	if disp%int64(enc.disp8N) != 0 {
+		return 0, false
+	}
+	d := disp / int64(enc.disp8N)
+	return int8(d), d >= -128 && d < 128
+}
+
+// encodeMemory computes the ModRM byte, SIB byte and displacement of a
+// memory operand.
+func (enc *encoder) encodeMemory(m Memory) error {
+	reg := byte(enc.reg&7) << 3
+
+	if m.Disp < -1<<31 || m.Disp >= 1<<31 {
+		return fmt.Errorf("displacement %#x out of range", m.Disp)
+	}
+	disp32 := make([]byte, 4)
+	binary.LittleEndian.PutUint32(disp32, uint32(m.Disp))
+
+	if m.RIP {
+		if !m.Base.IsNone() || !m.Index.IsNone() {
+			return fmt.Errorf("RIP-relative address can't have a base or an index")
+		}
+		enc.modRM = append([]byte{reg | 5}, disp32...)
+		return nil
+	}
+
+	scale, ok := scaleBits[m.Scale]
+	if !ok {
+		return fmt.Errorf("invalid scale %d", m.Scale)
+	}
+
+	addrSize := 0
+	for _, r := range []Register{m.Base, m.Index} {
+		if r.IsNone() || r.Class != x86db.RegisterClassGP {
+			continue
+		}
+		if r.Size != 32 && r.Size != 64 || addrSize != 0 && r.Size != addrSize {
+			return fmt.Errorf("invalid address registers")
+		}
+		addrSize = r.Size
+	}
+	if m.AddressSize != 0 {
+		if err := checkAddressSize(m, addrSize); err != nil {
+			return err
+		}
+		addrSize = m.AddressSize
+	}
+	enc.addrSize = addrSize == 32
+	if !m.Base.IsNone() && m.Base.Class != x86db.RegisterClassGP {
+		return fmt.Errorf("invalid base register '%s'", m.Base)
+	}
+
+	vsib := enc.e.VSIB != x86db.RegisterClassNone
+	if vsib && m.Index.Class != enc.e.VSIB {
+		return fmt.Errorf("vector index register required")
+	}
+	if !vsib && !m.Index.IsNone() && m.Index.Class != x86db.RegisterClassGP {
+		return fmt.Errorf("invalid index register '%s'", m.Index)
+	}
+	if !vsib && m.Index.Class == x86db.RegisterClassGP && m.Index.Num == 4 {
+		return fmt.Errorf("'%s' can't be used as index", m.Index)
+	}
+
+	index := byte(4)
+	if !m.Index.IsNone() {
+		enc.index = m.Index.Num
+		index = byte(m.Index.Num & 7)
+	}
+
+	// No base: disp32 with a SIB byte, a ModRM-only disp32 would be
+	// RIP-relative.
+	if m.Base.IsNone() {
+		enc.modRM = append([]byte{reg | 4, scale<<6 | index<<3 | 5}, disp32...)
+		return nil
+	}
+
+	enc.base = m.Base.Num
+	base := byte(m.Base.Num & 7)
+
+	var mod byte
+	var disp []byte
+	if d8, ok := enc.disp8(m.Disp); m.Disp == 0 && base != 5 {
+		mod = 0
+	} else if ok {
+		mod = 1
+		disp = []byte{byte(d8)}
+	} else {
+		mod = 2
+		disp = disp32
+	}
+
+	if m.Index.IsNone() && base != 4 && !vsib {
+		enc.modRM = append([]byte{mod<<6 | reg | base}, disp...)
+		return nil
+	}
+	enc.modRM = append([]byte{mod<<6 | reg | 4, scale<<6 | index<<3 | base}, disp...)
+	return nil
+}
+
+var segmentPrefixes = []byte{0x26, 0x2e, 0x36, 0x3e, 0x64, 0x65}
+
+// opcodeRegister returns the register added to the opcode (+r).
+func (enc *encoder) opcodeRegister() int {
+	if enc.e.Add != x86db.AddRegister || enc.e.OpcodeOperand < 0 {
+		return 0
+	}
+	return enc.register(enc.e.OpcodeOperand).Num
+}
+
+// addressPrefixes returns the segment override and address-size prefixes.
+func (enc *encoder) addressPrefixes() []byte {
+	var code []byte
+	if enc.mem != nil && !enc.mem.Segment.IsNone() {
+		code = append(code, segmentPrefixes[enc.mem.Segment.Num])
+	}
+	if enc.addrSize {
+		code = append(code, 0x67)
+	}
+	return code
+}
+
+// legacyPrefixes returns the prefixes, REX prefix and escape bytes of a
+// legacy encoding.
+func (enc *encoder) legacyPrefixes() ([]byte, error) {
+	e := enc.e
+	var code []byte
+
+	if e.Flags.Has(x86db.EncodingWait) {
+		code = append(code, 0x9b)
+	}
+	code = append(code, enc.addressPrefixes()...)
+	if e.OperandSize == x86db.Size16 && e.Prefix != 0x66 {
+		code = append(code, 0x66)
+	}
+	switch {
+	case e.Flags.Has(x86db.EncodingMustRep):
+		code = append(code, 0xf3)
+	case e.Flags.Has(x86db.EncodingMustRepNE):
+		code = append(code, 0xf2)
+	}
+	if e.Prefix != 0 {
+		code = append(code, e.Prefix)
+	}
+
+	var rex byte
+	if e.RexW() && !e.Flags.Has(x86db.EncodingNoRexW) {
+		rex |= 0x08
+	}
+	if enc.reg&8 != 0 {
+		rex |= 0x04
+	}
+	if enc.index&8 != 0 {
+		rex |= 0x02
+	}
+	if enc.rm&8 != 0 || enc.base&8 != 0 || enc.opcodeRegister()&8 != 0 {
+		rex |= 0x01
+	}
+	if rex != 0 || enc.rex {
+		if enc.noREX {
+			return nil, fmt.Errorf("ah, ch, dh and bh can't be used with a REX prefix")
+		}
+		code = append(code, 0x40|rex)
+	}
+
+	switch e.Map {
+	case x86db.Map0F:
+		code = append(code, 0x0f)
+	case x86db.Map0F38:
+		code = append(code, 0x0f, 0x38)
+	case x86db.Map0F3A:
+		code = append(code, 0x0f, 0x3a)
+	}
+
+	return code, nil
+}
+
+// opcode returns the opcode bytes, the register of +r opcodes added.
+func (enc *encoder) opcode() []byte {
+	if len(enc.e.Opcode) == 0 {
+		return nil
+	}
+	opcode := append([]byte(nil), enc.e.Opcode...)
+	opcode[len(opcode)-1] += byte(enc.opcodeRegister() & 7)
+	return opcode
+}
+
+// isImmediateToken returns true if code is an immediate of the code string.
+func isImmediateToken(code string) bool {
+	for t := x86db.ImmByte; t <= x86db.ImmJlen; t++ {
+		if t.String() == code {
+			return true
+		}
+	}
+	return false
+}
+
+func isModRMToken(code string) bool {
+	return code == "/r" || len(code) == 2 && code[0] == '/' && code[1] >= '0' && code[1] <= '7'
+}
+
+func isHexByte(code string) bool {
+	_, err := strconv.ParseUint(code, 16, 8)
+	return len(code) == 2 && err == nil
+}
+
+// appendTail appends the ModRM byte and what follows it in the code string:
+// immediates and literal bytes.
+func (enc *encoder) appendTail(code []byte) ([]byte, error) {
+	immediates := enc.e.Immediates
+	suffix := enc.e.Suffix
+	tail := false
+	jlen := -1
+
+	for _, token := range enc.insn.Pattern.Opcodes {
+		switch {
+		case isModRMToken(token):
+			code = append(code, enc.modRM...)
+			tail = true
+		case isImmediateToken(token):
+			imm := immediates[0]
+			immediates = immediates[1:]
+			if imm.Type == x86db.ImmJlen {
+				jlen = len(code)
+				code = append(code, 0)
+			} else {
+				b, err := enc.immediate(imm)
+				if err != nil {
+					return nil, err
+				}
+				code = append(code, b...)
+			}
+			tail = true
+		case tail && isHexByte(token) && len(suffix) > 0:
+			code = append(code, suffix[0])
+			suffix = suffix[1:]
+		}
+	}
+
+	if jlen >= 0 {
+		code[jlen] = byte(len(code) - jlen - 1)
+	}
+
+	return code, nil
+}
+
+// moffsAddressSize returns the address size of the moffs operand, 0 if none.
+func (enc *encoder) moffsAddressSize() int {
+	for _, imm := range enc.e.Immediates {
+		if imm.Type != x86db.ImmWordDwordQword || imm.Operand < 0 {
+			continue
+		}
+		if m, ok := enc.args[imm.Operand].(Memory); ok {
+			return m.AddressSize
+		}
+	}
+	return 0
+}
+
+// operandSize returns the operand size of the instruction.
+func (enc *encoder) operandSize() int {
+	switch enc.e.OperandSize {
+	case x86db.Size16:
+		return 16
+	case x86db.Size64:
+		return 64
+	}
+	return 32
+}
+
+// immediate returns the bytes of an immediate.
+func (enc *encoder) immediate(imm x86db.Immediate) ([]byte, error) {
+	var v int64
+	if imm.Operand >= 0 {
+		switch op := enc.args[imm.Operand].(type) {
+		case Immediate:
+			v = int64(op)
+		case Register:
+			// /is4
+			v = int64(op.Num) << 4
+		case Memory:
+			// moffs
+			if !op.Base.IsNone() || !op.Index.IsNone() || op.RIP {
+				return nil, fmt.Errorf("invalid offset '%s'", op)
+			}
+			if err := checkAddressSize(op, 0); err != nil {
+				return nil, err
+			}
+			v = op.Disp
+			if !op.Segment.IsNone() {
+				// The segment prefix has to come first, it's only
+				// supported through the ModRM forms.
+				return nil, fmt.Errorf("segment override not supported in '%s'", op)
+			}
+		}
+	}
+
+	var size int
+	min, max := int64(0), int64(0)
+	switch imm.Type {
+	case x86db.ImmByte, x86db.ImmUByte, x86db.ImmIs4:
+		size, min, max = 1, -1<<7, 1<<8-1
+	case x86db.ImmSByte, x86db.ImmRel8:
+		size, min, max = 1, -1<<7, 1<<7-1
+	case x86db.ImmWord, x86db.ImmSeg:
+		size, min, max = 2, -1<<15, 1<<16-1
+	case x86db.ImmDword:
+		size, min, max = 4, -1<<31, 1<<32-1
+	case x86db.ImmSDword:
+		size, min, max = 4, -1<<31, 1<<31-1
+	case x86db.ImmRel:
+		size, min, max = 4, -1<<31, 1<<31-1
+		if enc.operandSize() == 16 {
+			size, min, max = 2, -1<<15, 1<<15-1
+		}
+	case x86db.ImmWordDword:
+		size, min, max = 4, -1<<31, 1<<32-1
+		if enc.operandSize() == 16 {
+			size, min, max = 2, -1<<15, 1<<16-1
+		}
+	case x86db.ImmQword:
+		size = 8
+	case x86db.ImmWordDwordQword:
+		size = 8
+		if enc.addrSize {
+			size, min, max = 4, 0, 1<<32-1
+		}
+	}
+	if size < 8 && (v < min || v > max) {
+		return nil, fmt.Errorf("immediate %#x out of range for %s", v, imm.Type)
+	}
+
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(v))
+	return b[:size], nil
+}
@@ -0,0 +1,277 @@
+package encode
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/dlespiau/x86db"
+	"github.com/stretchr/testify/assert"
+)
+
+// form returns the instruction form of name with the given operands.
+func form(t *testing.T, db *x86db.DB, name, operands string) *x86db.Instruction {
+	for _, insn := range db.Lookup(name) {
+		if strings.Join(insn.Operands, ",") == operands {
+			return &insn
+		}
+	}
+	t.Fatalf("no form %s %s", name, operands)
+	return nil
+}
+
+var (
+	rax  = MustRegister("rax")
+	eax  = MustRegister("eax")
+	ecx  = MustRegister("ecx")
+	r12  = MustRegister("r12")
+	r13  = MustRegister("r13")
+	rbp  = MustRegister("rbp")
+	rsp  = MustRegister("rsp")
+	r9d  = MustRegister("r9d")
+	ah   = MustRegister("ah")
+	sil  = MustRegister("sil")
+	xmm1 = MustRegister("xmm1")
+	xmm9 = MustRegister("xmm9")
+)
+
+func TestEncodeLegacy(t *testing.T) {
+	db := x86db.NewDBWithOptions("../data/insns.dat",
+		x86db.LoadOptions{ExpandConditions: true})
+	assert.Nil(t, db.Open())
+
+	tests := []struct {
+		name     string
+		operands string
+		args     []Operand
+		code     string
+	}{
+		{"ADD", "rm64,imm8", []Operand{rax, Immediate(1)}, "4883c001"},
+		{"ADD", "reg32,reg32", []Operand{r9d, ecx}, "4101c9"},
+		{"ADD", "reg_eax,imm", []Operand{eax, Immediate(0x1000)}, "0500100000"},
+		{"MOV", "reg64,imm", []Operand{r12, Immediate(-1)},
+			"49bcffffffffffffffff"},
+		{"MOV", "reg64,mem", []Operand{rax, Memory{Base: rsp, Disp: 8}}, "488b442408"},
+		{"MOV", "reg64,mem", []Operand{rax, Memory{Base: r13}}, "498b4500"},
+		{"MOV", "reg64,mem", []Operand{rax, Memory{Base: rbp, Index: r12, Scale: 8,
+			Disp: 0x100}}, "4a8b84e500010000"},
+		{"MOV", "reg64,mem", []Operand{rax, Memory{Disp: 0x10}}, "488b042510000000"},
+		{"MOV", "reg64,mem", []Operand{rax, RIPRelative(-4, 0)}, "488b05fcffffff"},
+		{"MOV", "reg32,mem", []Operand{eax, Memory{Base: MustRegister("ebx")}}, "678b03"},
+		{"MOV", "reg8,reg8", []Operand{sil, MustRegister("al")}, "4088c6"},
+		{"MOV", "reg_al,mem_offs", []Operand{MustRegister("al"),
+			Memory{Disp: 0x1234}}, "a03412000000000000"},
+		{"MOV", "reg_al,mem_offs", []Operand{MustRegister("al"),
+			Memory{Disp: 0x1234, AddressSize: 32}}, "67a034120000"},
+		{"MOV", "reg32,mem", []Operand{eax, Memory{Disp: 0x10, AddressSize: 32}},
+			"678b042510000000"},
+		{"PUSH", "reg64", []Operand{r12}, "4154"},
+		{"SHL", "rm32,unity", []Operand{eax, Immediate(1)}, "d1e0"},
+		{"ADDPS", "xmmreg,xmmrm128", []Operand{xmm9, xmm1}, "440f58c9"},
+		{"PSHUFD", "xmmreg,mem,imm", []Operand{xmm1, Memory{Base: rax},
+			Immediate(0x1b)}, "660f70081b"},
+		{"POPCNT", "reg16,rm16", []Operand{MustRegister("ax"), MustRegister("cx")},
+			"66f30fb8c1"},
+		{"FSTSW", "reg_ax", []Operand{MustRegister("ax")}, "9bdfe0"},
+		{"FWAIT", "void", nil, "9b"},
+		{"PFADD", "mmxreg,mmxrm", []Operand{MustRegister("mm0"),
+			Memory{Base: rax, Disp: 1}}, "0f0f40019e"},
+		{"JNE", "imm", []Operand{Immediate(-2)}, "75fe"},
+		{"CALL", "imm64", []Operand{Immediate(0x10)}, "e810000000"},
+		{"MOV", "mem,reg_sreg", []Operand{Memory{Segment: MustRegister("fs"),
+			Base: rax}, MustRegister("ds")}, "648c18"},
+	}
+
+	for _, test := range tests {
+		insn := form(t, db, test.name, test.operands)
+		code, err := Encode(insn, test.args...)
+		assert.Nil(t, err, "%s %s", test.name, test.operands)
+		assert.Equal(t, test.code, hex.EncodeToString(code), "%s %s", test.name,
+			test.operands)
+	}
+}
+
+func TestEncodeVEX(t *testing.T) {
+	db := x86db.NewDBFromFile("../data/insns.dat")
+	assert.Nil(t, db.Open())
+
+	ymm1, ymm2, ymm3 := MustRegister("ymm1"), MustRegister("ymm2"), MustRegister("ymm3")
+	xmm10, xmm12 := MustRegister("xmm10"), MustRegister("xmm12")
+
+	tests := []struct {
+		name     string
+		operands string
+		args     []Operand
+		code     string
+	}{
+		// 2-byte VEX, NDS.
+		{"VADDPS", "ymmreg,ymmreg*,ymmrm256", []Operand{ymm1, ymm2, ymm3}, "c5ec58cb"},
+		// '*' operand omitted.
+		{"VADDPS", "ymmreg,ymmreg*,ymmrm256", []Operand{ymm1, ymm3}, "c5f458cb"},
+		// REX.B equivalent needs the 3-byte form.
+		{"VADDPS", "xmmreg,xmmreg*,xmmrm128", []Operand{xmm1, xmm10, xmm12},
+			"c4c12858cc"},
+		{"VADDPS", "ymmreg,ymmreg*,ymmrm256", []Operand{ymm1, ymm2,
+			Memory{Base: r12, Disp: 0x20}}, "c4c16c584c2420"},
+		// 0f38 map and W1.
+		{"VFMADD231PD", "ymmreg,ymmreg,ymmrm256", []Operand{ymm1, ymm2, ymm3},
+			"c4e2edb8cb"},
+		// NDD: the destination is in vvvv.
+		{"VPSLLD", "xmmreg,xmmreg*,imm8", []Operand{xmm1, xmm10, Immediate(3)},
+			"c4c17172f203"},
+		// is4.
+		{"VBLENDVPS", "xmmreg,xmmreg*,xmmrm128,xmmreg", []Operand{xmm1, xmm10,
+			xmm9, xmm12}, "c4c3294ac9c0"},
+		// lig.
+		{"VCVTSS2SI", "reg64,xmmrm32", []Operand{rax, xmm1}, "c4e1fa2dc1"},
+		// BMI, lz and wig.
+		{"ANDN", "reg64,reg64,rm64", []Operand{rax, r12, Memory{Base: rax}},
+			"c4e298f200"},
+		{"VZEROUPPER", "void", nil, "c5f877"},
+		// VSIB.
+		{"VPGATHERDD", "xmmreg,xmem32,xmmreg", []Operand{xmm1, Memory{Base: rax,
+			Index: xmm10, Scale: 4}, MustRegister("xmm2")}, "c4a269900c90"},
+		// XOP.
+		{"VPROTD", "xmmreg,xmmrm128*,imm8", []Operand{xmm1, xmm10, Immediate(1)},
+			"8fc878c2ca01"},
+		{"VPCMOV", "xmmreg,xmmreg*,xmmrm128,xmmreg", []Operand{xmm1, xmm10,
+			MustRegister("xmm3"), xmm12}, "8fe828a2cbc0"},
+	}
+
+	for _, test := range tests {
+		insn := form(t, db, test.name, test.operands)
+		code, err := Encode(insn, test.args...)
+		assert.Nil(t, err, "%s %s", test.name, test.operands)
+		assert.Equal(t, test.code, hex.EncodeToString(code), "%s %s", test.name,
+			test.operands)
+	}
+}
+
+func TestEncodeEVEX(t *testing.T) {
+	db := x86db.NewDBFromFile("../data/insns.dat")
+	assert.Nil(t, db.Open())
+
+	zmm1, zmm2, zmm3 := MustRegister("zmm1"), MustRegister("zmm2"), MustRegister("zmm3")
+	k1, k2 := MustRegister("k1"), MustRegister("k2")
+	r9 := MustRegister("r9")
+
+	tests := []struct {
+		name     string
+		operands string
+		args     []Operand
+		code     string
+	}{
+		{"VADDPS", "zmmreg|mask|z,zmmreg*,zmmrm512|b32|er", []Operand{zmm1, zmm2, zmm3},
+			"62f16c4858cb"},
+		// Opmask and zeroing.
+		{"VADDPS", "zmmreg|mask|z,zmmreg*,zmmrm512|b32|er", []Operand{
+			Masked{zmm1, k1, true}, zmm2, zmm3}, "62f16cc958cb"},
+		{"VMOVUPS", "mem512|mask,zmmreg", []Operand{
+			Masked{Memory{Base: rax, Disp: 0x80}, k1, false}, zmm1}, "62f17c49114802"},
+		// Embedded rounding and SAE.
+		{"VADDPS", "zmmreg|mask|z,zmmreg*,zmmrm512|b32|er", []Operand{zmm1, zmm2,
+			Rounded{zmm3, RoundDown}}, "62f16c3858cb"},
+		{"VADDSS", "xmmreg|mask|z,xmmreg*,xmmrm32|er", []Operand{Masked{xmm1, k1, true},
+			MustRegister("xmm2"), Rounded{MustRegister("xmm3"), RoundZero}},
+			"62f16ef958cb"},
+		{"VCMPPS", "kreg|mask,zmmreg,zmmrm512|b32|sae,imm8", []Operand{Masked{k1, k2, false},
+			zmm2, Rounded{zmm3, SAE}, Immediate(0)}, "62f16c1ac2cb00"},
+		{"VUCOMISS", "xmmreg,xmmrm32|sae", []Operand{xmm1, Rounded{MustRegister("xmm2"), SAE}},
+			"62f17c182eca"},
+		// Broadcast, the displacement is scaled by the element size.
+		{"VADDPS", "zmmreg|mask|z,zmmreg*,zmmrm512|b32|er", []Operand{zmm1, zmm2,
+			Memory{Base: rax, Disp: 0x40, Broadcast: 32}}, "62f16c58584810"},
+		// High registers and disp8*N.
+		{"VADDPS", "zmmreg|mask|z,zmmreg*,zmmrm512|b32|er", []Operand{
+			MustRegister("zmm17"), MustRegister("zmm22"), Memory{Base: r9, Disp: 0x40}},
+			"62c14c40584901"},
+		{"VADDPS", "zmmreg|mask|z,zmmreg*,zmmrm512|b32|er", []Operand{zmm1, zmm2,
+			MustRegister("zmm28")}, "62916c4858cc"},
+		// Not a multiple of N: disp32.
+		{"VADDPS", "zmmreg|mask|z,zmmreg*,zmmrm512|b32|er", []Operand{zmm1, zmm2,
+			Memory{Base: rax, Disp: 0x41}}, "62f16c48588841000000"},
+		// VSIB with a high index.
+		{"VPGATHERDD", "zmmreg|mask,zmem32", []Operand{Masked{zmm1, k1, false},
+			Memory{Base: rax, Index: MustRegister("zmm20"), Scale: 4, Disp: 0x100}},
+			"62f27d41904ca040"},
+	}
+
+	for _, test := range tests {
+		insn := form(t, db, test.name, test.operands)
+		code, err := Encode(insn, test.args...)
+		assert.Nil(t, err, "%s %s", test.name, test.operands)
+		assert.Equal(t, test.code, hex.EncodeToString(code),
+			"%s %s", test.name, test.operands)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	db := x86db.NewDBWithOptions("../data/insns.dat", x86db.LoadOptions{})
+	assert.Nil(t, db.Open())
+
+	tests := []struct {
+		name     string
+		operands string
+		args     []Operand
+	}{
+		// Invalid operands.
+		{"ADD", "reg32,reg32", []Operand{rax, ecx}},
+		// ah can't be encoded with REX.
+		{"MOV", "reg8,reg8", []Operand{ah, sil}},
+		// rsp can't be an index.
+		{"MOV", "reg64,mem", []Operand{rax, Memory{Base: rax, Index: rsp}}},
+		// Immediate out of range.
+		{"ADD", "rm64,imm8", []Operand{rax, Immediate(0x80)}},
+		// Address size not matching the address registers.
+		{"MOV", "reg64,mem", []Operand{rax, Memory{Base: rax, AddressSize: 32}}},
+		{"MOV", "reg_al,mem_offs", []Operand{MustRegister("al"),
+			Memory{Disp: 0x1234, AddressSize: 16}}},
+		// Not in 64-bit mode.
+		{"AAA", "void", nil},
+		// Condition template.
+		{"Jcc", "imm", []Operand{Immediate(0)}},
+		// Pseudo-instruction.
+		{"RESB", "imm", []Operand{Immediate(1)}},
+		// Decorators not allowed by the form.
+		{"VADDPS", "xmmreg|mask|z,xmmreg*,xmmrm128|b32", []Operand{xmm1, xmm1,
+			Rounded{xmm1, RoundUp}}},
+		{"VADDPS", "xmmreg|mask|z,xmmreg*,xmmrm128|b32", []Operand{xmm1, xmm1,
+			Memory{Base: rax, Broadcast: 64}}},
+		{"VMOVUPS", "mem512|mask,zmmreg", []Operand{
+			Masked{Memory{Base: rax}, MustRegister("k1"), true}, MustRegister("zmm1")}},
+		{"VADDPS", "xmmreg,xmmreg*,xmmrm128", []Operand{Masked{xmm1,
+			MustRegister("k1"), false}, xmm1, xmm1}},
+		// k0 isn't an opmask.
+		{"VADDPS", "xmmreg|mask|z,xmmreg*,xmmrm128|b32", []Operand{Masked{xmm1,
+			MustRegister("k0"), false}, xmm1, xmm1}},
+	}
+
+	for _, test := range tests {
+		insn := form(t, db, test.name, test.operands)
+		_, err := Encode(insn, test.args...)
+		assert.NotNil(t, err, "%s %s", test.name, test.operands)
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	db := x86db.NewDBFromFile("../data/insns.dat")
+	assert.Nil(t, db.Open())
+
+	tests := []struct {
+		mnemonic string
+		args     []Operand
+		code     string
+	}{
+		{"SBB", []Operand{eax, Immediate(1)}, "83d801"},
+		{"MOV", []Operand{rax, Immediate(1)}, "b801000000"},
+		{"JMP", []Operand{Immediate(0x10)}, "eb10"},
+		{"CMPEQPS", []Operand{xmm1, xmm9}, "410fc2c900"},
+		{"FWAIT", nil, "9b"},
+	}
+
+	for _, test := range tests {
+		code, err := Assemble(db, test.mnemonic, test.args...)
+		assert.Nil(t, err, test.mnemonic)
+		assert.Equal(t, test.code, hex.EncodeToString(code), test.mnemonic)
+	}
+}
@@ -0,0 +1,175 @@
+package encode
+
+import (
+	"fmt"
+
+	"github.com/dlespiau/x86db"
+)
+
+// Masked is an operand with an opmask register, {k1} to {k7}, and optionally
+// zeroing-masking, {z}. It's used for the destination of AVX-512 instructions.
+type Masked struct {
+	Operand
+	Mask    Register
+	Zeroing bool
+}
+
+// String implements the stringer interface for Masked.
+func (m Masked) String() string {
+	s := fmt.Sprintf("%s{%s}", m.Operand, m.Mask)
+	if m.Zeroing {
+		s += "{z}"
+	}
+	return s
+}
+
+// Spec implements Operand.
+func (m Masked) Spec() x86db.OperandSpec {
+	spec := m.Operand.Spec()
+	spec.Mask = true
+	spec.Zeroing = m.Zeroing
+	return spec
+}
+
+// Rounding is an embedded rounding mode or suppress-all-exceptions.
+type Rounding int
+
+const (
+	RoundNearest Rounding = iota
+	RoundDown
+	RoundUp
+	RoundZero
+	// SAE suppresses all exceptions without changing the rounding mode.
+	SAE
+)
+
+var roundingNames = []string{
+	RoundNearest: "rn-sae",
+	RoundDown:    "rd-sae",
+	RoundUp:      "ru-sae",
+	RoundZero:    "rz-sae",
+	SAE:          "sae",
+}
+
+// String implements the stringer interface for Rounding.
+func (r Rounding) String() string {
+	if r < 0 || int(r) >= len(roundingNames) {
+		return fmt.Sprintf("Rounding(%d)", int(r))
+	}
+	return roundingNames[r]
+}
+
+// Rounded is a register operand of a form allowing embedded rounding (|er) or
+// suppress-all-exceptions (|sae).
+type Rounded struct {
+	Register
+	Rounding Rounding
+}
+
+// String implements the stringer interface for Rounded.
+func (r Rounded) String() string {
+	return fmt.Sprintf("%s{%s}", r.Register, r.Rounding)
+}
+
+// Spec implements Operand.
+func (r Rounded) Spec() x86db.OperandSpec {
+	spec := r.Register.Spec()
+	spec.Raw = r.String()
+	spec.SAE = r.Rounding == SAE
+	spec.Rounding = !spec.SAE
+	return spec
+}
+
+// unwrapDecorators replaces the Masked and Rounded operands by the operand
+// they decorate, recording the decorators in the encoder.
+func (enc *encoder) unwrapDecorators() error {
+	for i, arg := range enc.args {
+		switch op := arg.(type) {
+		case Masked:
+			if op.Mask.Class != x86db.RegisterClassMask || op.Mask.Num == 0 {
+				return fmt.Errorf("invalid opmask register '%s'", op.Mask)
+			}
+			enc.mask = op.Mask.Num
+			enc.zeroing = op.Zeroing
+			enc.args[i] = op.Operand
+		case Rounded:
+			enc.rounding = &op.Rounding
+			enc.args[i] = op.Register
+		case Memory:
+			if op.Broadcast != 0 {
+				enc.broadcast = true
+			}
+		}
+	}
+
+	if enc.zeroing && enc.mask == 0 {
+		return fmt.Errorf("zeroing-masking requires an opmask register")
+	}
+	return nil
+}
+
+// evexPrefix returns the address prefixes and the EVEX prefix of the
+// instruction.
+func (enc *encoder) evexPrefix() ([]byte, error) {
+	e := enc.e
+	// The byte registers 4 to 7 are spl, bpl, sil and dil.
+	if enc.noREX {
+		return nil, fmt.Errorf("ah, ch, dh and bh can't be used with %s", e.Space)
+	}
+
+	// k0 can't be used as the completion mask of gathers and scatters.
+	if e.VSIB != x86db.RegisterClassNone && enc.mask == 0 {
+		return nil, fmt.Errorf("opmask register required")
+	}
+
+	code := enc.addressPrefixes()
+
+	// The high bits of the r/m register are in B and X, X and V' hold the
+	// high bits of a VSIB index.
+	rm := enc.rm | enc.base
+	index := enc.index
+	if enc.mem == nil {
+		index = enc.rm >> 1
+	}
+	vvvv := enc.vvvv()
+
+	r := byte(enc.reg>>3&1) ^ 1
+	x := byte(index>>3&1) ^ 1
+	b := byte(rm>>3&1) ^ 1
+	r2 := byte(enc.reg>>4&1) ^ 1
+	v2 := byte(vvvv>>4&1) ^ 1
+	if enc.mem != nil && e.VSIB != x86db.RegisterClassNone {
+		v2 = byte(enc.index>>4&1) ^ 1
+	}
+
+	var w, z, bit byte
+	if e.W == x86db.W1 {
+		w = 1
+	}
+	if enc.zeroing {
+		z = 1
+	}
+
+	var ll byte
+	switch {
+	case enc.rounding != nil && enc.mem != nil:
+		return nil, fmt.Errorf("embedded rounding and SAE require register operands")
+	case enc.rounding != nil:
+		bit = 1
+		if *enc.rounding != SAE {
+			ll = byte(*enc.rounding)
+		}
+	case !e.LIG && e.VectorLength == 256:
+		ll = 1
+	case !e.LIG && e.VectorLength == 512:
+		ll = 2
+	}
+	if enc.broadcast {
+		bit = 1
+	}
+
+	return append(code, 0x62,
+		r<<7|x<<6|b<<5|r2<<4|byte(e.Map),
+		w<<7|(^byte(vvvv)&0xf)<<3|1<<2|ppBits(e.Prefix),
+		z<<7|ll<<5|bit<<4|v2<<3|byte(enc.mask)), nil
+}
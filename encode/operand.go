@@ -0,0 +1,282 @@
+package encode
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dlespiau/x86db"
+)
+
+// Operand is a concrete operand: a Register, a Memory reference or an
+// Immediate.
+type Operand interface {
+	fmt.Stringer
+	// Spec returns the description of the operand used to match it against
+	// the operands of instruction forms.
+	Spec() x86db.OperandSpec
+}
+
+// Register is a machine register. The zero value means no register.
+type Register struct {
+	Name  string
+	Class x86db.RegisterClass
+	// Size is the size of the register in bits.
+	Size int
+	// Num is the register number, as encoded in instructions.
+	Num int
+}
+
+// gpNames lists the general purpose registers by size, in register number
+// order.
+var gpNames = []struct {
+	size  int
+	names [16]string
+}{
+	{8, [16]string{"al", "cl", "dl", "bl", "spl", "bpl", "sil", "dil",
+		"r8b", "r9b", "r10b", "r11b", "r12b", "r13b", "r14b", "r15b"}},
+	{16, [16]string{"ax", "cx", "dx", "bx", "sp", "bp", "si", "di",
+		"r8w", "r9w", "r10w", "r11w", "r12w", "r13w", "r14w", "r15w"}},
+	{32, [16]string{"eax", "ecx", "edx", "ebx", "esp", "ebp", "esi", "edi",
+		"r8d", "r9d", "r10d", "r11d", "r12d", "r13d", "r14d", "r15d"}},
+	{64, [16]string{"rax", "rcx", "rdx", "rbx", "rsp", "rbp", "rsi", "rdi",
+		"r8", "r9", "r10", "r11", "r12", "r13", "r14", "r15"}},
+}
+
+// highByteNames are the legacy byte registers numbered 4 to 7. They can't be
+// encoded with a REX prefix.
+var highByteNames = []string{"ah", "ch", "dh", "bh"}
+
+// registerFiles are the numbered register files, eg. xmm0 to xmm31.
+var registerFiles = []struct {
+	prefix string
+	class  x86db.RegisterClass
+	size   int
+	count  int
+}{
+	{"cr", x86db.RegisterClassControl, 64, 16},
+	{"dr", x86db.RegisterClassDebug, 64, 16},
+	{"st", x86db.RegisterClassFPU, 80, 8},
+	{"mm", x86db.RegisterClassMMX, 64, 8},
+	{"xmm", x86db.RegisterClassXMM, 128, 32},
+	{"ymm", x86db.RegisterClassYMM, 256, 32},
+	{"zmm", x86db.RegisterClassZMM, 512, 32},
+	{"k", x86db.RegisterClassMask, 64, 8},
+	{"bnd", x86db.RegisterClassBound, 128, 4},
+}
+
+var segmentNames = []string{"es", "cs", "ss", "ds", "fs", "gs"}
+
+// registerKey identifies a register by number. The size is only used for
+// general purpose registers.
+type registerKey struct {
+	class x86db.RegisterClass
+	size  int
+	num   int
+}
+
+func keyOf(class x86db.RegisterClass, size, num int) registerKey {
+	if class != x86db.RegisterClassGP {
+		size = 0
+	}
+	return registerKey{class, size, num}
+}
+
+// registersByNumber doesn't hold the ah, ch, dh and bh registers.
+var registers, registersByNumber = buildRegisters()
+
+func buildRegisters() (map[string]Register, map[registerKey]Register) {
+	registers := map[string]Register{}
+	registersByNumber := map[registerKey]Register{}
+	add := func(r Register) {
+		registers[r.Name] = r
+		if !r.isHighByte() {
+			registersByNumber[keyOf(r.Class, r.Size, r.Num)] = r
+		}
+	}
+
+	for _, gp := range gpNames {
+		for n, name := range gp.names {
+			add(Register{name, x86db.RegisterClassGP, gp.size, n})
+		}
+	}
+	for n, name := range highByteNames {
+		add(Register{name, x86db.RegisterClassGP, 8, n + 4})
+	}
+	for n, name := range segmentNames {
+		add(Register{name, x86db.RegisterClassSegment, 16, n})
+	}
+	for _, file := range registerFiles {
+		for n := 0; n < file.count; n++ {
+			add(Register{fmt.Sprintf("%s%d", file.prefix, n), file.class,
+				file.size, n})
+		}
+	}
+
+	return registers, registersByNumber
+}
+
+// RegisterFromString returns the register with the given name, eg. "r10d" or
+// "zmm31".
+func RegisterFromString(name string) (Register, error) {
+	r, ok := registers[strings.ToLower(name)]
+	if !ok {
+		return Register{}, fmt.Errorf("no register with name '%s'", name)
+	}
+	return r, nil
+}
+
+// RegisterFromNumber returns the register of the given class, size and
+// number. The size is only used for general purpose registers. For the byte
+// registers 4 to 7, rex selects spl, bpl, sil and dil over ah, ch, dh and bh.
+func RegisterFromNumber(class x86db.RegisterClass, size, num int, rex bool) (Register, error) {
+	if class == x86db.RegisterClassGP && size == 8 && num >= 4 && num < 8 && !rex {
+		return registers[highByteNames[num-4]], nil
+	}
+
+	if r, ok := registersByNumber[keyOf(class, size, num)]; ok {
+		return r, nil
+	}
+	return Register{}, fmt.Errorf("no %s register %d of size %d", class, num, size)
+}
+
+// MustRegister is like RegisterFromString but panics if the register doesn't
+// exist.
+func MustRegister(name string) Register {
+	r, err := RegisterFromString(name)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// IsNone returns true for the zero Register.
+func (r Register) IsNone() bool {
+	return r.Name == ""
+}
+
+func (r Register) isHighByte() bool {
+	return r.Class == x86db.RegisterClassGP && r.Size == 8 && r.Num >= 4 &&
+		r.Num < 8 && r.Name[1] == 'h'
+}
+
+// needsREX returns true for the byte registers only accessible with a REX
+// prefix.
+func (r Register) needsREX() bool {
+	return r.Class == x86db.RegisterClassGP && r.Size == 8 && r.Num >= 4 &&
+		!r.isHighByte()
+}
+
+// String implements the stringer interface for Register.
+func (r Register) String() string {
+	return r.Name
+}
+
+// Spec implements Operand.
+func (r Register) Spec() x86db.OperandSpec {
+	spec, err := x86db.OperandSpecFromString(r.Name)
+	if err != nil {
+		panic(err)
+	}
+	return spec
+}
+
+// Memory is a memory reference: Segment:[Base + Index*Scale + Disp]. With RIP
+// set, the address is Disp relative to the end of the instruction.
+type Memory struct {
+	Segment Register
+	Base    Register
+	Index   Register
+	// Scale is 1, 2, 4 or 8, 0 meaning 1.
+	Scale int
+	Disp  int64
+	RIP   bool
+	// Size is the size of the access in bits, 0 when unsized.
+	Size int
+	// Broadcast is the size in bits of the element broadcasted to all the
+	// elements of the vector, {1toN}, 0 for no broadcast. EVEX only.
+	Broadcast int
+	// AddressSize is the address size in bits, 32 or 64. 0 means the size
+	// of the base and index registers, 64 without registers.
+	AddressSize int
+}
+
+// RIPRelative returns a RIP-relative memory reference.
+func RIPRelative(disp int64, size int) Memory {
+	return Memory{RIP: true, Disp: disp, Size: size}
+}
+
+var sizeNames = map[int]string{
+	8: "byte", 16: "word", 32: "dword", 64: "qword", 80: "tword",
+	128: "oword", 256: "yword", 512: "zword",
+}
+
+// String implements the stringer interface for Memory, in nasm syntax.
+func (m Memory) String() string {
+	var parts []string
+	if !m.Base.IsNone() {
+		parts = append(parts, m.Base.Name)
+	}
+	if !m.Index.IsNone() {
+		index := m.Index.Name
+		if m.Scale > 1 {
+			index += fmt.Sprintf("*%d", m.Scale)
+		}
+		parts = append(parts, index)
+	}
+
+	addr := strings.Join(parts, "+")
+	switch {
+	case m.RIP:
+		addr = fmt.Sprintf("rel %#x", m.Disp)
+	case m.Disp < 0 && addr != "":
+		addr += fmt.Sprintf("-%#x", -m.Disp)
+	case m.Disp != 0 || addr == "":
+		if addr != "" {
+			addr += "+"
+		}
+		addr += fmt.Sprintf("%#x", m.Disp)
+	}
+	if !m.Segment.IsNone() {
+		addr = m.Segment.Name + ":" + addr
+	}
+
+	if size, ok := sizeNames[m.Broadcast]; ok {
+		return fmt.Sprintf("%s bcst [%s]", size, addr)
+	}
+	if size, ok := sizeNames[m.Size]; ok {
+		return fmt.Sprintf("%s [%s]", size, addr)
+	}
+	return "[" + addr + "]"
+}
+
+// Spec implements Operand.
+func (m Memory) Spec() x86db.OperandSpec {
+	op := x86db.Operand{Raw: m.String(), Kind: x86db.OperandMemory, Size: m.Size,
+		Broadcast: m.Broadcast}
+	switch m.Index.Class {
+	case x86db.RegisterClassXMM, x86db.RegisterClassYMM, x86db.RegisterClassZMM:
+		op.VSIB = m.Index.Class
+	}
+	return x86db.OperandSpec{Operand: op}
+}
+
+// Immediate is an immediate value. For relative branches, it's the
+// displacement from the end of the instruction.
+type Immediate int64
+
+// String implements the stringer interface for Immediate.
+func (i Immediate) String() string {
+	if i < 0 {
+		return fmt.Sprintf("-%#x", -int64(i))
+	}
+	return fmt.Sprintf("%#x", int64(i))
+}
+
+// Spec implements Operand.
+func (i Immediate) Spec() x86db.OperandSpec {
+	return x86db.OperandSpec{
+		Operand:  x86db.Operand{Raw: i.String(), Kind: x86db.OperandImmediate},
+		Value:    int64(i),
+		HasValue: true,
+	}
+}
@@ -0,0 +1,64 @@
+package encode
+
+import (
+	"fmt"
+
+	"github.com/dlespiau/x86db"
+)
+
+// ppBits returns the pp field encoding the mandatory prefix.
+func ppBits(prefix byte) byte {
+	switch prefix {
+	case 0x66:
+		return 1
+	case 0xf3:
+		return 2
+	case 0xf2:
+		return 3
+	}
+	return 0
+}
+
+// vvvv returns the register number encoded in vvvv, 0 if none.
+func (enc *encoder) vvvv() int {
+	if enc.e.VVVVOperand < 0 {
+		return 0
+	}
+	return enc.register(enc.e.VVVVOperand).Num
+}
+
+// vexPrefix returns the address prefixes and the VEX or XOP prefix of the
+// instruction. The 2-byte VEX prefix is used when X, B, W and the opcode map
+// don't need to be encoded.
+func (enc *encoder) vexPrefix() ([]byte, error) {
+	e := enc.e
+	// The byte registers 4 to 7 are spl, bpl, sil and dil.
+	if enc.noREX {
+		return nil, fmt.Errorf("ah, ch, dh and bh can't be used with %s", e.Space)
+	}
+
+	code := enc.addressPrefixes()
+
+	r := byte(enc.reg>>3&1) ^ 1
+	x := byte(enc.index>>3&1) ^ 1
+	b := byte((enc.rm|enc.base)>>3&1) ^ 1
+	vvvv := ^byte(enc.vvvv()) & 0xf
+	var w, l byte
+	if e.W == x86db.W1 {
+		w = 1
+	}
+	if e.VectorLength == 256 && !e.LIG {
+		l = 1
+	}
+	pp := ppBits(e.Prefix)
+
+	if e.Space == x86db.EncodingVEX && e.Map == x86db.Map0F && w == 0 && x == 1 && b == 1 {
+		return append(code, 0xc5, r<<7|vvvv<<3|l<<2|pp), nil
+	}
+
+	escape := byte(0xc4)
+	if e.Space == x86db.EncodingXOP {
+		escape = 0x8f
+	}
+	return append(code, escape, r<<7|x<<6|b<<5|byte(e.Map), w<<7|vvvv<<3|l<<2|pp), nil
+}
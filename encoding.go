@@ -0,0 +1,657 @@
+package x86db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EncodingSpace is the family of encodings an instruction belongs to.
+type EncodingSpace int
+
+const (
+	EncodingLegacy EncodingSpace = iota
+	EncodingVEX
+	EncodingXOP
+	EncodingEVEX
+)
+
+var encodingSpaceNames = []string{
+	EncodingLegacy: "legacy",
+	EncodingVEX:    "vex",
+	EncodingXOP:    "xop",
+	EncodingEVEX:   "evex",
+}
+
+// String implements the stringer interface for EncodingSpace.
+func (s EncodingSpace) String() string {
+	if s < 0 || int(s) >= len(encodingSpaceNames) {
+		return fmt.Sprintf("EncodingSpace(%d)", int(s))
+	}
+	return encodingSpaceNames[s]
+}
+
+// OpcodeMap is the opcode map the primary opcode is looked up in. The values
+// are the ones of the VEX/XOP/EVEX mmmmm field.
+type OpcodeMap int
+
+const (
+	MapPrimary OpcodeMap = 0
+	Map0F      OpcodeMap = 1
+	Map0F38    OpcodeMap = 2
+	Map0F3A    OpcodeMap = 3
+	MapXOP8    OpcodeMap = 8
+	MapXOP9    OpcodeMap = 9
+	MapXOPA    OpcodeMap = 10
+)
+
+// String implements the stringer interface for OpcodeMap.
+func (m OpcodeMap) String() string {
+	switch m {
+	case MapPrimary:
+		return ""
+	case Map0F:
+		return "0f"
+	case Map0F38:
+		return "0f38"
+	case Map0F3A:
+		return "0f3a"
+	}
+	return fmt.Sprintf("m%d", int(m))
+}
+
+// SizeMarker is an operand-size or address-size marker of the code string.
+type SizeMarker int
+
+const (
+	// SizeNone means the code string doesn't specify a size.
+	SizeNone SizeMarker = iota
+	Size16
+	Size32
+	// Size64 requires REX.W when used as operand size.
+	Size64
+	// Size64NoW is a 64-bit operand size that doesn't need REX.W (o64nw).
+	Size64NoW
+	// SizeDefault is the default size of the current mode (odf, adf).
+	SizeDefault
+)
+
+// VVVVRole is the role of the register encoded in VEX.vvvv.
+type VVVVRole int
+
+const (
+	VVVVNone VVVVRole = iota
+	// VVVVNDS is a non-destructive source.
+	VVVVNDS
+	// VVVVNDD is a non-destructive destination.
+	VVVVNDD
+	// VVVVDDS is a destructive source (destination and source).
+	VVVVDDS
+)
+
+// WBit is the value required for the W bit of VEX, XOP and EVEX prefixes.
+type WBit int
+
+const (
+	WNone WBit = iota
+	W0
+	W1
+	WIG
+)
+
+// OpcodeAddition is a value added to the last opcode byte.
+type OpcodeAddition int
+
+const (
+	AddNone OpcodeAddition = iota
+	// AddRegister adds the register number of the 'r' operand (+r).
+	AddRegister
+	// AddCondition adds the condition code (+c).
+	AddCondition
+)
+
+// ImmediateType describes an immediate, relative offset or is4 byte emitted
+// after the ModRM byte.
+type ImmediateType int
+
+const (
+	ImmByte ImmediateType = iota
+	ImmSByte
+	ImmUByte
+	ImmWord
+	ImmDword
+	ImmSDword
+	ImmQword
+	// ImmWordDword is a word or a dword depending on the operand size.
+	ImmWordDword
+	// ImmWordDwordQword is sized by the address size (moffs).
+	ImmWordDwordQword
+	ImmRel8
+	// ImmRel is a 16 or 32-bit relative offset depending on the operand
+	// size.
+	ImmRel
+	// ImmSeg is the segment selector of a far pointer.
+	ImmSeg
+	// ImmIs4 is a register number stored in bits [7:4] of an imm8.
+	ImmIs4
+	// ImmJlen is the length of the jump that follows (jcc over jmp).
+	ImmJlen
+)
+
+type immediateTypeInfo struct {
+	imm  ImmediateType
+	name string
+	size int
+}
+
+var immediateTypeTab = []immediateTypeInfo{
+	{ImmByte, "ib", 8},
+	{ImmSByte, "ib,s", 8},
+	{ImmUByte, "ib,u", 8},
+	{ImmWord, "iw", 16},
+	{ImmDword, "id", 32},
+	{ImmSDword, "id,s", 32},
+	{ImmQword, "iq", 64},
+	{ImmWordDword, "iwd", 0},
+	{ImmWordDwordQword, "iwdq", 0},
+	{ImmRel8, "rel8", 8},
+	{ImmRel, "rel", 0},
+	{ImmSeg, "seg", 16},
+	{ImmIs4, "/is4", 8},
+	{ImmJlen, "jlen", 8},
+}
+
+// String implements the stringer interface for ImmediateType.
+func (t ImmediateType) String() string {
+	for _, info := range immediateTypeTab {
+		if info.imm == t {
+			return info.name
+		}
+	}
+	return fmt.Sprintf("ImmediateType(%d)", int(t))
+}
+
+// Size returns the size in bits of the immediate, 0 when it depends on the
+// operand or address size.
+func (t ImmediateType) Size() int {
+	for _, info := range immediateTypeTab {
+		if info.imm == t {
+			return info.size
+		}
+	}
+	return 0
+}
+
+// Length returns the number of bytes of the immediate for an operand size
+// and an address size in bits, the moffs address being sized by the address
+// size.
+func (t ImmediateType) Length(operandSize, addressSize int) int {
+	switch t {
+	case ImmWordDword, ImmRel:
+		if operandSize == 16 {
+			return 2
+		}
+		return 4
+	case ImmWordDwordQword:
+		return addressSize / 8
+	}
+	return t.Size() / 8
+}
+
+// Immediate is an immediate field of the encoding.
+type Immediate struct {
+	Type ImmediateType
+	// Operand is the index of the operand providing the value, -1 if none.
+	Operand int
+}
+
+// EncodingFlags are the code string keywords that don't emit bytes by
+// themselves but constrain the encoding.
+type EncodingFlags uint32
+
+const (
+	EncodingHLE EncodingFlags = 1 << iota
+	EncodingHLEXR
+	EncodingHLENL
+	EncodingNoRexB
+	EncodingNoRexX
+	EncodingNoRexR
+	EncodingNoRexW
+	EncodingNoHi
+	EncodingNoF3
+	EncodingNoRep
+	EncodingRepE
+	EncodingMustRep
+	EncodingMustRepNE
+	EncodingWait
+	EncodingJcc8
+	EncodingJmp8
+	EncodingRexL
+	EncodingResb
+	EncodingNP
+)
+
+type encodingFlagInfo struct {
+	flag EncodingFlags
+	name string
+	help string
+}
+
+var encodingFlagTab = []encodingFlagInfo{
+	{EncodingHLE, "hle", "HLE prefixes allowed with LOCK"},
+	{EncodingHLEXR, "hlexr", "XRELEASE allowed without LOCK"},
+	{EncodingHLENL, "hlenl", "HLE prefixes allowed without LOCK"},
+	{EncodingNoRexB, "norexb", "REX.B not allowed"},
+	{EncodingNoRexX, "norexx", "REX.X not allowed"},
+	{EncodingNoRexR, "norexr", "REX.R not allowed"},
+	{EncodingNoRexW, "norexw", "REX.W ignored"},
+	{EncodingNoHi, "nohi", "High byte registers not allowed"},
+	{EncodingNoF3, "nof3", "F3 prefix not allowed"},
+	{EncodingNoRep, "norep", "REP prefixes not allowed"},
+	{EncodingRepE, "repe", "REPE/REPNE prefixes allowed"},
+	{EncodingMustRep, "mustrep", "REP prefix required"},
+	{EncodingMustRepNE, "mustrepne", "REPNE prefix required"},
+	{EncodingWait, "wait", "Preceded by a WAIT (9B) instruction"},
+	{EncodingJcc8, "jcc8", "Short conditional jump, optimization only"},
+	{EncodingJmp8, "jmp8", "Short jump, optimization only"},
+	{EncodingRexL, "rex.l", "LOCK prefix used as REX.R"},
+	{EncodingResb, "resb", "Reserve space"},
+	{EncodingNP, "np", "No SSE prefix allowed"},
+}
+
+// Has returns true if all the flags in f2 are set in f.
+func (f EncodingFlags) Has(f2 EncodingFlags) bool {
+	return f&f2 == f2
+}
+
+// ModRM describes the ModRM byte of the encoding.
+type ModRM struct {
+	// Digit is the value of the reg field for /0 to /7, -1 for /r.
+	Digit int
+	// Reg is the index of the operand held in the reg field, -1 if none.
+	Reg int
+	// RM is the index of the operand held in the r/m field, -1 if none.
+	RM int
+	// Index is the index of the register operand used as the index of a
+	// MIB memory operand, -1 if none.
+	Index int
+}
+
+// Encoding is the structured form of the code string (Pattern.Opcodes) of an
+// instruction.
+type Encoding struct {
+	Space EncodingSpace
+	Flags EncodingFlags
+	// OperandSize and AddressSize are the o16/o32/o64/o64nw/odf and
+	// a16/a32/a64/adf markers.
+	OperandSize SizeMarker
+	AddressSize SizeMarker
+	// Prefix is the mandatory prefix: 0x66, 0xf2, 0xf3 or 0 for none. For
+	// VEX, XOP and EVEX instructions, it's the prefix encoded in pp.
+	Prefix byte
+	Map    OpcodeMap
+	// Opcode holds the opcode bytes, not including the map escape bytes.
+	Opcode []byte
+	// Add is added to the last byte of Opcode.
+	Add OpcodeAddition
+	// ModRM is nil when the instruction has no ModRM byte.
+	ModRM *ModRM
+	// Suffix holds the literal bytes following the ModRM byte, eg. the
+	// 3DNow! opcode or the predicate of CMPEQPS.
+	Suffix     []byte
+	Immediates []Immediate
+	// VSIB is the vector index register file of VSIB addressing.
+	VSIB RegisterClass
+
+	// The following fields are only meaningful for VEX, XOP and EVEX.
+
+	// VectorLength is the vector length in bits; 0 when L is ignored.
+	VectorLength int
+	// LIG is set when the vector length is ignored.
+	LIG  bool
+	W    WBit
+	VVVV VVVVRole
+	// VVVVOperand is the index of the operand encoded in vvvv, -1 if none.
+	VVVVOperand int
+	TupleType   TupleType
+
+	// OpcodeOperand is the index of the operand added to the opcode byte
+	// (+r), -1 if none.
+	OpcodeOperand int
+}
+
+// RexW returns true if the encoding requires REX.W (or VEX.W/EVEX.W set to 1).
+func (e *Encoding) RexW() bool {
+	if e.Space == EncodingLegacy {
+		return e.OperandSize == Size64
+	}
+	return e.W == W1
+}
+
+// Disp8N returns the disp8*N scaling factor of the encoding, see
+// TupleType.Disp8N.
+func (e *Encoding) Disp8N(broadcast bool) int {
+	if e.Space != EncodingEVEX || e.TupleType == TupleNone {
+		return 1
+	}
+	vectorLength := e.VectorLength
+	if vectorLength == 0 {
+		vectorLength = 128
+	}
+	return e.TupleType.Disp8N(vectorLength, e.W == W1, broadcast)
+}
+
+// operandPositions maps the operand letters of a pattern to operand indexes.
+// A '+' between two letters means both refer to the same operand.
+func operandPositions(operands string) map[byte]int {
+	pos := make(map[byte]int)
+	n := 0
+	for i := 0; i < len(operands); i++ {
+		c := operands[i]
+		if c == '+' {
+			continue
+		}
+		if _, ok := pos[c]; !ok && c != '-' {
+			pos[c] = n
+		}
+		if i+1 < len(operands) && operands[i+1] == '+' {
+			continue
+		}
+		n++
+	}
+	return pos
+}
+
+func position(pos map[byte]int, c byte) int {
+	if n, ok := pos[c]; ok {
+		return n
+	}
+	return -1
+}
+
+func parseVEX(e *Encoding, str string) error {
+	fields := strings.Split(str, ".")
+
+	switch fields[0] {
+	case "vex":
+		e.Space = EncodingVEX
+	case "xop":
+		e.Space = EncodingXOP
+	case "evex":
+		e.Space = EncodingEVEX
+	}
+
+	for _, field := range fields[1:] {
+		switch field {
+		case "nds":
+			e.VVVV = VVVVNDS
+		case "ndd":
+			e.VVVV = VVVVNDD
+		case "dds":
+			e.VVVV = VVVVDDS
+		case "128", "l0", "lz":
+			e.VectorLength = 128
+		case "256", "l1":
+			e.VectorLength = 256
+		case "512":
+			e.VectorLength = 512
+		case "lig":
+			e.LIG = true
+		case "66", "p1":
+			e.Prefix = 0x66
+		case "f3":
+			e.Prefix = 0xf3
+		case "f2":
+			e.Prefix = 0xf2
+		case "np", "p0":
+			e.Prefix = 0
+		case "0f":
+			e.Map = Map0F
+		case "0f38":
+			e.Map = Map0F38
+		case "0f3a":
+			e.Map = Map0F3A
+		case "w0":
+			e.W = W0
+		case "w1":
+			e.W = W1
+		case "wig":
+			e.W = WIG
+		default:
+			if len(field) > 1 && field[0] == 'm' {
+				m, err := strconv.Atoi(field[1:])
+				if err != nil {
+					return fmt.Errorf("invalid map in '%s'", str)
+				}
+				e.Map = OpcodeMap(m)
+				continue
+			}
+			return fmt.Errorf("unknown field '%s' in '%s'", field, str)
+		}
+	}
+
+	return nil
+}
+
+func parseHexByte(str string) (byte, bool) {
+	if len(str) != 2 {
+		return 0, false
+	}
+	b, err := strconv.ParseUint(str, 16, 8)
+	if err != nil {
+		return 0, false
+	}
+	return byte(b), true
+}
+
+// tokenError is an error about a single token of a pattern.
+type tokenError struct {
+	token string
+	err   error
+}
+
+func (e *tokenError) Error() string {
+	return e.err.Error()
+}
+
+// Encoding parses the code string of the pattern.
+func (p *Pattern) Encoding() (*Encoding, error) {
+	pos := operandPositions(p.Operands)
+	e := &Encoding{
+		TupleType:     p.TupleType,
+		VVVVOperand:   -1,
+		OpcodeOperand: -1,
+	}
+
+	// Letters naming the operands of the next immediates, the second
+	// immediate of an instruction uses the 'j' operand.
+	immOperands := []byte{'i', 'j'}
+	// Mandatory prefixes and map escapes are only recognized before the
+	// primary opcode.
+	prefixOK := true
+
+	for _, code := range p.Opcodes {
+		if strings.HasPrefix(code, "vex.") || strings.HasPrefix(code, "xop.") ||
+			strings.HasPrefix(code, "evex.") {
+			if err := parseVEX(e, code); err != nil {
+				return nil, &tokenError{code, err}
+			}
+			e.VVVVOperand = position(pos, 'v')
+			continue
+		}
+
+		if flag := encodingFlagFromString(code); flag != 0 {
+			e.Flags |= flag
+			continue
+		}
+
+		switch code {
+		case "o16":
+			e.OperandSize = Size16
+			continue
+		case "o32":
+			e.OperandSize = Size32
+			continue
+		case "o64":
+			e.OperandSize = Size64
+			continue
+		case "o64nw":
+			e.OperandSize = Size64NoW
+			continue
+		case "odf":
+			e.OperandSize = SizeDefault
+			continue
+		case "a16":
+			e.AddressSize = Size16
+			continue
+		case "a32":
+			e.AddressSize = Size32
+			continue
+		case "a64":
+			e.AddressSize = Size64
+			continue
+		case "adf":
+			e.AddressSize = SizeDefault
+			continue
+		case "f2i":
+			e.Prefix = 0xf2
+			continue
+		case "f3i":
+			e.Prefix = 0xf3
+			continue
+		case "vsibx", "vm32x", "vm64x":
+			e.VSIB = RegisterClassXMM
+			continue
+		case "vsiby", "vm32y", "vm64y":
+			e.VSIB = RegisterClassYMM
+			continue
+		case "vsibz":
+			e.VSIB = RegisterClassZMM
+			continue
+		case "/r":
+			e.ModRM = &ModRM{
+				Digit: -1,
+				Reg:   position(pos, 'r'),
+				RM:    position(pos, 'm'),
+				Index: position(pos, 'x'),
+			}
+			prefixOK = false
+			continue
+		}
+
+		if len(code) == 2 && code[0] == '/' && code[1] >= '0' && code[1] <= '7' {
+			e.ModRM = &ModRM{
+				Digit: int(code[1] - '0'),
+				Reg:   -1,
+				RM:    position(pos, 'm'),
+				Index: position(pos, 'x'),
+			}
+			prefixOK = false
+			continue
+		}
+
+		if imm, ok := immediateTypeFromString(code); ok {
+			operand := -1
+			switch imm {
+			case ImmIs4:
+				operand = position(pos, 's')
+			case ImmSeg:
+				operand = position(pos, 'i')
+			case ImmJlen:
+			default:
+				if len(immOperands) > 0 {
+					operand = position(pos, immOperands[0])
+					immOperands = immOperands[1:]
+				}
+			}
+			e.Immediates = append(e.Immediates, Immediate{imm, operand})
+			prefixOK = false
+			continue
+		}
+
+		// Opcode bytes, possibly with a register or condition added.
+		token := code
+		add := AddNone
+		if strings.HasSuffix(code, "+r") {
+			add = AddRegister
+			code = code[:len(code)-2]
+		} else if strings.HasSuffix(code, "+c") {
+			add = AddCondition
+			code = code[:len(code)-2]
+		}
+
+		b, ok := parseHexByte(code)
+		if !ok {
+			return nil, &tokenError{token, fmt.Errorf("unknown code '%s'", token)}
+		}
+
+		// Bytes after the ModRM byte or an immediate. In the "jcc over
+		// jmp" form, this is the opcode of the jmp.
+		if e.ModRM != nil || len(e.Immediates) > 0 {
+			e.Suffix = append(e.Suffix, b)
+			continue
+		}
+
+		if prefixOK && e.Space == EncodingLegacy && add == AddNone {
+			if e.Map == MapPrimary && (b == 0x66 || b == 0xf2 || b == 0xf3) {
+				e.Prefix = b
+				continue
+			}
+			if e.Map == MapPrimary && len(e.Opcode) == 0 && b == 0x0f {
+				e.Map = Map0F
+				continue
+			}
+			if e.Map == Map0F && len(e.Opcode) == 0 && (b == 0x38 || b == 0x3a) {
+				e.Map = Map0F38
+				if b == 0x3a {
+					e.Map = Map0F3A
+				}
+				continue
+			}
+		}
+		prefixOK = false
+
+		e.Opcode = append(e.Opcode, b)
+		if add != AddNone {
+			e.Add = add
+			if add == AddRegister {
+				e.OpcodeOperand = position(pos, 'r')
+			}
+		}
+	}
+
+	if e.Flags.Has(EncodingNP) {
+		e.Prefix = 0
+	}
+
+	// A few Cyrix instructions are just "0f 38" or "0f 3a".
+	if len(e.Opcode) == 0 && (e.Map == Map0F38 || e.Map == Map0F3A) &&
+		e.Space == EncodingLegacy {
+		b := byte(0x38)
+		if e.Map == Map0F3A {
+			b = 0x3a
+		}
+		e.Map = Map0F
+		e.Opcode = []byte{b}
+	}
+
+	return e, nil
+}
+
+func encodingFlagFromString(name string) EncodingFlags {
+	for _, info := range encodingFlagTab {
+		if info.name == name {
+			return info.flag
+		}
+	}
+	return 0
+}
+
+func immediateTypeFromString(name string) (ImmediateType, bool) {
+	for _, info := range immediateTypeTab {
+		if info.name == name {
+			return info.imm, true
+		}
+	}
+	return 0, false
+}
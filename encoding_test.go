@@ -0,0 +1,163 @@
+package x86db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternEncoding(t *testing.T) {
+	tests := []struct {
+		pattern string
+		valid   bool
+		golden  Encoding
+	}{
+		{"mi: o32 83 /3 ib,s", true, Encoding{
+			OperandSize:   Size32,
+			Opcode:        []byte{0x83},
+			ModRM:         &ModRM{Digit: 3, Reg: -1, RM: 0, Index: -1},
+			Immediates:    []Immediate{{ImmSByte, 1}},
+			VVVVOperand:   -1,
+			OpcodeOperand: -1,
+		}},
+		{"rm: o64 nof3 0f bc /r", true, Encoding{
+			Flags:         EncodingNoF3,
+			OperandSize:   Size64,
+			Map:           Map0F,
+			Opcode:        []byte{0xbc},
+			ModRM:         &ModRM{Digit: -1, Reg: 0, RM: 1, Index: -1},
+			VVVVOperand:   -1,
+			OpcodeOperand: -1,
+		}},
+		{"rm: 66 0f 38 dc /r", true, Encoding{
+			Prefix:        0x66,
+			Map:           Map0F38,
+			Opcode:        []byte{0xdc},
+			ModRM:         &ModRM{Digit: -1, Reg: 0, RM: 1, Index: -1},
+			VVVVOperand:   -1,
+			OpcodeOperand: -1,
+		}},
+		{"rm: o64nw 0f 0f /r 86", true, Encoding{
+			OperandSize:   Size64NoW,
+			Map:           Map0F,
+			Opcode:        []byte{0x0f},
+			ModRM:         &ModRM{Digit: -1, Reg: 0, RM: 1, Index: -1},
+			Suffix:        []byte{0x86},
+			VVVVOperand:   -1,
+			OpcodeOperand: -1,
+		}},
+		{"r: o32 0f c8+r", true, Encoding{
+			OperandSize:   Size32,
+			Map:           Map0F,
+			Opcode:        []byte{0xc8},
+			Add:           AddRegister,
+			VVVVOperand:   -1,
+			OpcodeOperand: 0,
+		}},
+		{"i: odf 0f 80+c rel", true, Encoding{
+			OperandSize:   SizeDefault,
+			Map:           Map0F,
+			Opcode:        []byte{0x80},
+			Add:           AddCondition,
+			Immediates:    []Immediate{{ImmRel, 0}},
+			VVVVOperand:   -1,
+			OpcodeOperand: -1,
+		}},
+		{"ji: o32 9a id iw", true, Encoding{
+			OperandSize:   Size32,
+			Opcode:        []byte{0x9a},
+			Immediates:    []Immediate{{ImmDword, 1}, {ImmWord, 0}},
+			VVVVOperand:   -1,
+			OpcodeOperand: -1,
+		}},
+		{"rvm: vex.nds.256.66.0f 58 /r", true, Encoding{
+			Space:         EncodingVEX,
+			Prefix:        0x66,
+			Map:           Map0F,
+			Opcode:        []byte{0x58},
+			ModRM:         &ModRM{Digit: -1, Reg: 0, RM: 2, Index: -1},
+			VectorLength:  256,
+			VVVV:          VVVVNDS,
+			VVVVOperand:   1,
+			OpcodeOperand: -1,
+		}},
+		{"rvsm: vex.m3.w1.nds.l0.p1 69 /r /is4", true, Encoding{
+			Space:         EncodingVEX,
+			Prefix:        0x66,
+			Map:           Map0F3A,
+			Opcode:        []byte{0x69},
+			ModRM:         &ModRM{Digit: -1, Reg: 0, RM: 3, Index: -1},
+			Immediates:    []Immediate{{ImmIs4, 2}},
+			VectorLength:  128,
+			W:             W1,
+			VVVV:          VVVVNDS,
+			VVVVOperand:   1,
+			OpcodeOperand: -1,
+		}},
+		{"vmi: xop.ndd.lz.m9.w0 01 /1", true, Encoding{
+			Space:         EncodingXOP,
+			Map:           MapXOP9,
+			Opcode:        []byte{0x01},
+			ModRM:         &ModRM{Digit: 1, Reg: -1, RM: 1, Index: -1},
+			VectorLength:  128,
+			W:             W0,
+			VVVV:          VVVVNDD,
+			VVVVOperand:   0,
+			OpcodeOperand: -1,
+		}},
+		{"rvmi:fv: evex.nds.512.66.0f3a.w0 03 /r ib", true, Encoding{
+			Space:         EncodingEVEX,
+			Prefix:        0x66,
+			Map:           Map0F3A,
+			Opcode:        []byte{0x03},
+			ModRM:         &ModRM{Digit: -1, Reg: 0, RM: 2, Index: -1},
+			Immediates:    []Immediate{{ImmByte, 3}},
+			VectorLength:  512,
+			W:             W0,
+			VVVV:          VVVVNDS,
+			VVVVOperand:   1,
+			TupleType:     TupleFV,
+			OpcodeOperand: -1,
+		}},
+		{"rm: vex.128.66.0f 6f /q", false, Encoding{}},
+		{"rm: vex.128.66.0f.w2 6f /r", false, Encoding{}},
+	}
+
+	for _, test := range tests {
+		p, err := patternFromString(test.pattern)
+		assert.Nil(t, err)
+
+		e, err := p.Encoding()
+		if !test.valid {
+			assert.NotNil(t, err, test.pattern)
+			continue
+		}
+
+		assert.Nil(t, err, test.pattern)
+		assert.Equal(t, &test.golden, e, test.pattern)
+	}
+}
+
+func TestImmediateLength(t *testing.T) {
+	tests := []struct {
+		imm                      ImmediateType
+		operandSize, addressSize int
+		golden                   int
+	}{
+		{ImmByte, 32, 64, 1},
+		{ImmSDword, 64, 64, 4},
+		{ImmQword, 64, 64, 8},
+		{ImmWordDword, 16, 64, 2},
+		{ImmWordDword, 64, 64, 4},
+		{ImmRel, 16, 64, 2},
+		{ImmRel, 32, 64, 4},
+		{ImmWordDwordQword, 32, 64, 8},
+		{ImmWordDwordQword, 32, 32, 4},
+		{ImmSeg, 32, 64, 2},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.golden, test.imm.Length(test.operandSize, test.addressSize),
+			test.imm.String())
+	}
+}
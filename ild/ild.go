@@ -0,0 +1,321 @@
+// Package ild computes the length of x86 instructions without decoding them.
+//
+// Length only looks at the bytes needed to find the instruction boundaries:
+// prefixes, opcode, ModRM, SIB, displacement and immediates. It doesn't
+// allocate and is meant for tools that need to walk code, eg. binary patching.
+//
+//	for len(code) > 0 {
+//		n, err := ild.Length(code, 64)
+//		if err != nil {
+//			return err
+//		}
+//		code = code[n:]
+//	}
+//
+// The tables are generated from the instruction patterns of the DB, an
+// instruction the DB doesn't know about has an invalid opcode.
+package ild
+
+//go:generate go run ../cmd/x86db-ildgen -o tables.go
+
+import "errors"
+
+// MaxLength is the maximum length of an instruction.
+const MaxLength = 15
+
+var (
+	// ErrTruncated is returned when code ends before the instruction.
+	ErrTruncated = errors.New("truncated instruction")
+	// ErrInvalidOpcode is returned when no instruction has the opcode.
+	ErrInvalidOpcode = errors.New("invalid opcode")
+	// ErrTooLong is returned when the instruction is longer than MaxLength.
+	ErrTooLong = errors.New("instruction too long")
+	// ErrInvalidMode is returned when mode isn't 16, 32 or 64.
+	ErrInvalidMode = errors.New("invalid mode")
+)
+
+// The operand size states selecting the length of the immediates.
+const (
+	stateNone = iota
+	state66
+	stateW
+	numStates
+)
+
+const (
+	// flagModRM is set when the opcode is followed by a ModRM byte.
+	flagModRM = 1 << iota
+	// flagMoffs is set when the instruction has an address sized offset.
+	flagMoffs
+	// flagModRMReg is set when ModRM.mod is ignored, the ModRM byte always
+	// encoding registers.
+	flagModRMReg
+)
+
+// A table entry describes the bytes following the opcode byte: the number of
+// immediate bytes, including opcode suffixes, of each operand size state in a
+// nibble then the flags. 0 is an invalid opcode. The entries with a select bit
+// set are the offset in selectors of the entries selected by the mandatory
+// prefix or ModRM.reg.
+const (
+	flagsShift   = 12
+	selectDigit  = 1 << 30
+	selectPrefix = 1 << 31
+)
+
+// The kinds of the bytes starting an instruction.
+const (
+	kindOpcode = iota
+	kindSegment
+	kindOpsize
+	kindAdsize
+	kindRep
+	kindREX
+	kindEscape
+	kindVEX2
+	kindVEX3
+	kindXOP
+	kindEVEX
+)
+
+var byteKinds = func() (kinds [256]uint8) {
+	for _, b := range []byte{0x26, 0x2e, 0x36, 0x3e, 0x64, 0x65, 0xf0} {
+		kinds[b] = kindSegment
+	}
+	for b := 0x40; b < 0x50; b++ {
+		kinds[b] = kindREX
+	}
+	kinds[0x66] = kindOpsize
+	kinds[0x67] = kindAdsize
+	kinds[0xf2] = kindRep
+	kinds[0xf3] = kindRep
+	kinds[0x0f] = kindEscape
+	kinds[0xc5] = kindVEX2
+	kinds[0xc4] = kindVEX3
+	kinds[0x8f] = kindXOP
+	kinds[0x62] = kindEVEX
+	return
+}()
+
+// The bytes following a ModRM byte, by address size: bit 7 is set when a SIB
+// byte follows, the other bits are the length of the displacement.
+const modrmSIB = 0x80
+
+var modrm16, modrm32 = func() (m16, m32 [256]uint8) {
+	for b := 0; b < 256; b++ {
+		mod, rm := b>>6, b&7
+		switch {
+		case mod == 0 && rm == 6, mod == 2:
+			m16[b] = 2
+		case mod == 1:
+			m16[b] = 1
+		}
+		switch {
+		case mod == 0 && rm == 5, mod == 2:
+			m32[b] = 4
+		case mod == 1:
+			m32[b] = 1
+		}
+		if mod != 3 && rm == 4 {
+			m32[b] |= modrmSIB
+		}
+	}
+	return
+}()
+
+// Length returns the length of the instruction at the start of code in mode,
+// 16, 32 or 64 bits.
+func Length(code []byte, mode int) (int, error) {
+	var t int
+	switch mode {
+	case 16:
+		t = 0
+	case 32:
+		t = 1
+	case 64:
+		t = 2
+	default:
+		return 0, ErrInvalidMode
+	}
+
+	var rex, opsize, adsize, rep byte
+	var kind uint8
+	pos := 0
+	for ; ; pos++ {
+		if pos >= len(code) {
+			return 0, ErrTruncated
+		}
+		if pos >= MaxLength {
+			return 0, ErrTooLong
+		}
+		b := code[pos]
+		kind = byteKinds[b]
+		if kind == kindREX && mode == 64 {
+			rex = b
+			continue
+		}
+		if kind < kindSegment || kind > kindRep {
+			break
+		}
+		switch kind {
+		case kindOpsize:
+			opsize = b
+		case kindAdsize:
+			adsize = b
+		case kindRep:
+			rep = b
+		}
+		// A REX prefix is ignored when it isn't the last prefix.
+		rex = 0
+	}
+
+	// The byte following c4, c5 and 62 is a ModRM byte with mod != 3 for
+	// LES, LDS and BOUND outside of 64-bit mode. The one following 8f has
+	// ModRM.reg == 0 for POP.
+	if kind >= kindVEX2 {
+		vex := false
+		if pos+1 < len(code) {
+			next := code[pos+1]
+			if kind == kindXOP {
+				vex = next&0x1f >= 8
+			} else {
+				vex = mode == 64 || next>>6 == 3
+			}
+		} else if mode == 64 && kind != kindXOP {
+			// c4, c5 and 62 are always prefixes in 64-bit mode.
+			return 0, ErrTruncated
+		}
+		if !vex {
+			kind = kindOpcode
+		} else if rex|opsize|rep != 0 {
+			return 0, ErrInvalidOpcode
+		}
+	}
+
+	w := rex&0x08 != 0
+	space, m, pp := spaceLegacy, 0, 0
+	switch kind {
+	case kindVEX2:
+		space, m = spaceVEX, 1
+		pp = int(code[pos+1] & 3)
+		pos += 2
+	case kindVEX3, kindXOP:
+		if pos+2 >= len(code) {
+			return 0, ErrTruncated
+		}
+		space, m = spaceVEX, int(code[pos+1]&0x1f)
+		if kind == kindXOP {
+			space = spaceXOP
+		}
+		w = code[pos+2]&0x80 != 0
+		pp = int(code[pos+2] & 3)
+		pos += 3
+	case kindEVEX:
+		if pos+3 >= len(code) {
+			return 0, ErrTruncated
+		}
+		space, m = spaceEVEX, int(code[pos+1]&7)
+		w = code[pos+2]&0x80 != 0
+		pp = int(code[pos+2] & 3)
+		pos += 4
+	case kindEscape:
+		m = 1
+		pos++
+		if pos < len(code) && code[pos] == 0x38 {
+			m = 2
+			pos++
+		} else if pos < len(code) && code[pos] == 0x3a {
+			m = 3
+			pos++
+		}
+		fallthrough
+	default:
+		// The last of f2 and f3, or 66, is the mandatory prefix. The
+		// VEX pp field encodes 66, f3 and f2 in the same order.
+		switch {
+		case rep == 0xf3:
+			pp = 2
+		case rep == 0xf2:
+			pp = 3
+		case opsize != 0:
+			pp = 1
+		}
+	}
+
+	if m >= numMaps {
+		return 0, ErrInvalidOpcode
+	}
+	if pos >= len(code) {
+		return 0, ErrTruncated
+	}
+	table := tables[t][space][m]
+	if table == nil {
+		return 0, ErrInvalidOpcode
+	}
+	entry := table[code[pos]]
+	pos++
+	if entry&selectPrefix != 0 {
+		entry = selectors[int(entry&^selectPrefix)+pp]
+	}
+	if entry&selectDigit != 0 {
+		if pos >= len(code) {
+			return 0, ErrTruncated
+		}
+		entry = selectors[int(entry&^selectDigit)+int(code[pos]>>3&7)]
+	}
+	if entry == 0 {
+		return 0, ErrInvalidOpcode
+	}
+	flags := entry >> flagsShift
+
+	addressSize := mode
+	if adsize != 0 {
+		addressSize = [...]int{16: 32, 32: 16, 64: 32}[mode]
+	}
+
+	if flags&flagModRM != 0 {
+		if pos >= len(code) {
+			return 0, ErrTruncated
+		}
+		modrm := code[pos]
+		pos++
+		switch {
+		case modrm >= 0xc0 || flags&flagModRMReg != 0:
+		case addressSize == 16:
+			pos += int(modrm16[modrm])
+		default:
+			extra := modrm32[modrm]
+			if extra&modrmSIB != 0 {
+				if pos >= len(code) {
+					return 0, ErrTruncated
+				}
+				// No base with mod == 0 means a 32-bit displacement.
+				if modrm < 0x40 && code[pos]&7 == 5 {
+					pos += 4
+				}
+				pos++
+			}
+			pos += int(extra &^ modrmSIB)
+		}
+	}
+
+	state := stateNone
+	switch {
+	case w:
+		state = stateW
+	case opsize != 0 && space == spaceLegacy:
+		state = state66
+	}
+	pos += int(entry >> uint(4*state) & 0xf)
+	if flags&flagMoffs != 0 {
+		pos += addressSize / 8
+	}
+
+	if pos > MaxLength {
+		return 0, ErrTooLong
+	}
+	if pos > len(code) {
+		return 0, ErrTruncated
+	}
+	return pos, nil
+}
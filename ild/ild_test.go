@@ -0,0 +1,111 @@
+package ild
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var lengthTests = []struct {
+	code   string
+	mode   int
+	length int
+	err    error
+}{
+	{"90", 64, 1, nil},
+	{"4883c001", 64, 4, nil},
+	{"4a8b84e500010000", 64, 8, nil},
+	{"488b0510000000", 64, 7, nil},
+	{"48b88877665544332211", 64, 10, nil},
+	{"b844332211", 64, 5, nil},
+	{"66b83412", 64, 4, nil},
+	{"48a18877665544332211", 64, 10, nil},
+	{"67a144332211", 64, 6, nil},
+	{"f0480fb111", 64, 5, nil},
+	{"f3a4", 64, 2, nil},
+	{"e8fbffffff", 64, 5, nil},
+	{"660f3a0fc108", 64, 6, nil},
+	{"f30fb8c1", 64, 4, nil},
+	{"0f0fc19e", 64, 4, nil},
+	{"8fc0", 64, 2, nil},
+	{"9b", 64, 1, nil},
+	// VEX, XOP and EVEX.
+	{"c4e2edb8cb", 64, 5, nil},
+	{"c5f877", 64, 3, nil},
+	{"8fe828a2cbc0", 64, 6, nil},
+	{"62f16cc958cb", 64, 6, nil},
+	{"62f27d41904ca040", 64, 8, nil},
+	// 32-bit mode.
+	{"c40e", 32, 2, nil},
+	{"620e", 32, 2, nil},
+	{"c5f877", 32, 3, nil},
+	{"d50a", 32, 2, nil},
+	{"0f2040", 32, 3, nil},
+	{"668b4604", 32, 4, nil},
+	{"678b4604", 32, 4, nil},
+	{"a144332211", 32, 5, nil},
+	// 16-bit mode.
+	{"8b4604", 16, 3, nil},
+	{"8b0e3412", 16, 4, nil},
+	{"678b0424", 16, 4, nil},
+	{"66b878563412", 16, 6, nil},
+	{"e80010", 16, 3, nil},
+	// Errors.
+	{"", 64, 0, ErrTruncated},
+	{"4883c0", 64, 0, ErrTruncated},
+	{"0f", 64, 0, ErrTruncated},
+	{"c4e2", 64, 0, ErrTruncated},
+	{"c5", 64, 0, ErrTruncated},
+	{"62", 64, 0, ErrTruncated},
+	{"62f17d", 64, 0, ErrTruncated},
+	{"06", 64, 0, ErrInvalidOpcode},
+	{"66c5f877", 64, 0, ErrInvalidOpcode},
+	{strings.Repeat("66", 15) + "90", 64, 0, ErrTooLong},
+	{"90", 8, 0, ErrInvalidMode},
+}
+
+func TestLength(t *testing.T) {
+	for _, test := range lengthTests {
+		code, _ := hex.DecodeString(test.code)
+		n, err := Length(code, test.mode)
+		assert.Equal(t, test.err, err, test.code)
+		assert.Equal(t, test.length, n, test.code)
+	}
+}
+
+// benchmarkCode returns about 1MB of the valid 64-bit instructions of
+// lengthTests.
+func benchmarkCode() []byte {
+	var insns []byte
+	for _, test := range lengthTests {
+		if test.mode == 64 && test.err == nil {
+			code, _ := hex.DecodeString(test.code)
+			insns = append(insns, code...)
+		}
+	}
+
+	var code []byte
+	for len(code) < 1<<20 {
+		code = append(code, insns...)
+	}
+	return code
+}
+
+func BenchmarkLength(b *testing.B) {
+	code := benchmarkCode()
+	b.SetBytes(int64(len(code)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for c := code; len(c) > 0; {
+			n, err := Length(c, 64)
+			if err != nil {
+				b.Fatal(err)
+			}
+			c = c[n:]
+		}
+	}
+}
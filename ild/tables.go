@@ -0,0 +1,678 @@
+// generated by x86db-ildgen
+// DO NOT EDIT
+
+package ild
+
+const (
+	spaceLegacy = 0
+	spaceVEX    = 1
+	spaceXOP    = 2
+	spaceEVEX   = 3
+	numMaps     = 11
+)
+
+var selectors = [...]uint32{
+	0x9000, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x9111, 0x9111, 0x9111, 0x9111, 0x9111, 0x9111, 0x0, 0x9111,
+	0x9111, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x9111,
+	0x9242, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x9242,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x0, 0x9000,
+	0x9111, 0x0, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9242, 0x0, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x0, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x9000, 0x0, 0x0, 0x0, 0x0, 0x9111, 0x0,
+	0x9111, 0x0, 0x9111, 0x0, 0x0, 0x0, 0x9111, 0x0,
+	0x0, 0x0, 0x9111, 0x0, 0x0, 0x0, 0x9111, 0x9111,
+	0x0, 0x0, 0x9111, 0x9111, 0x40000064, 0x4000006c, 0x40000064, 0x40000064,
+	0x9222, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x40000078, 0x9000, 0x9222, 0x40000000, 0x9000, 0x40000000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x0, 0x0,
+	0x40000000, 0x40000000, 0x40000088, 0x40000000, 0x8242, 0x8242, 0x9000, 0x8242,
+	0x0, 0x0, 0x0, 0x0, 0x9111, 0x9111, 0x9111, 0x9111,
+	0x0, 0x9000, 0x0, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x0, 0x9000, 0x0, 0x9000, 0x0, 0x9000, 0x9000, 0x9000,
+	0x0, 0x0, 0x0, 0x9000, 0x0, 0x9000, 0x9000, 0x0,
+	0x0, 0x9111, 0x0, 0x0, 0x9000, 0x9000, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x0, 0x0, 0x0, 0x9000, 0x9000,
+	0x9000, 0x0, 0x9000, 0x0, 0x0, 0x9111, 0x9111, 0x9111,
+	0x0, 0x4000005c, 0x0, 0x0, 0x0, 0x4000006c, 0x0, 0x0,
+	0x8000, 0x0, 0x0, 0x0, 0x9000, 0x9000, 0x0, 0x9000,
+	0x0, 0x0, 0x9000, 0x9000, 0x0, 0x0, 0x0, 0x0,
+	0x400000e0, 0x0, 0x0, 0x0, 0x9111, 0x9111, 0x0, 0x0,
+	0x9000, 0x0, 0x0, 0x0, 0x0, 0x9000, 0x9000, 0x9000,
+	0x0, 0x0, 0x0, 0x0, 0x400000f4, 0x0, 0x0, 0x0,
+	0x9000, 0x0, 0x9000, 0x9000, 0x0, 0x0, 0x0, 0x9111,
+	0x9111, 0x0, 0x0, 0x0, 0x0, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x4000010c, 0x0, 0x0, 0x0,
+	0x0, 0x9000, 0x0, 0x0, 0x0, 0x0, 0x9000, 0x0,
+	0x40000118, 0x0, 0x0, 0x0, 0x40000038, 0x0, 0x0, 0x0,
+	0x9444, 0x0, 0x0, 0x0, 0x9444, 0x9444, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x4000012c, 0x0, 0x0, 0x0,
+	0x9111, 0x9111, 0x9111, 0x0, 0x9111, 0x0, 0x9111, 0x0,
+	0x0, 0x40000138, 0x0, 0x0, 0x0, 0x9000, 0x9000, 0x0,
+	0x0, 0x9000, 0x9000, 0x0, 0x0, 0x40000144, 0x0, 0x0,
+	0x9424, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x9424,
+	0x9424, 0x0, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x8424, 0x8424, 0x9000, 0x8424, 0x9424, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x9444,
+}
+
+var table0 = [256]uint32{
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8242, 0x8000, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8242, 0x8000, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8242, 0x8000, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8242, 0x8000, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8242, 0x0, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8242, 0x0, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8242, 0x0, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8242, 0x0, 0x8000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x9000, 0x9000, 0x0, 0x0, 0x0, 0x0,
+	0x8242, 0x9242, 0x8111, 0x9111, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111,
+	0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111,
+	0x9111, 0x9242, 0x9111, 0x9111, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x40000000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x8464, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0xa000, 0xa000, 0xa000, 0xa000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8111, 0x8242, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111,
+	0x8242, 0x8242, 0x8242, 0x8242, 0x8242, 0x8242, 0x8242, 0x8242,
+	0x40000008, 0x40000008, 0x8222, 0x8000, 0x9000, 0x9000, 0x40000010, 0x40000018,
+	0x8333, 0x8000, 0x8222, 0x8000, 0x8000, 0x8111, 0x8000, 0x8000,
+	0x40000020, 0x40000020, 0x40000020, 0x40000020, 0x8111, 0x8111, 0x8000, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111,
+	0x8242, 0x8242, 0x8464, 0x8111, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x0, 0x8000, 0x0, 0x0, 0x8000, 0x8000, 0x40000028, 0x40000030,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x40000038, 0x40000040,
+}
+
+var table1 = [256]uint32{
+	0x40000040, 0x40000048, 0x9000, 0x9000, 0x0, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x0, 0x8000, 0x0, 0x40000050, 0x8000, 0x9111,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0xd000, 0xd000, 0xd000, 0xd000, 0xd000, 0x0, 0xd000, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x40000000, 0x8000,
+	0x8000, 0x8000, 0x8000, 0x0, 0x8000, 0x8000, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x80000058, 0x80000058, 0x9000, 0x9000,
+	0x9111, 0x4000005c, 0x4000005c, 0x80000074, 0x9000, 0x9000, 0x9000, 0x8000,
+	0x80000080, 0x9000, 0x40000000, 0x40000000, 0x80000084, 0x80000084, 0x9000, 0x9000,
+	0x8442, 0x8442, 0x8442, 0x8442, 0x8442, 0x8442, 0x8442, 0x8442,
+	0x8442, 0x8442, 0x8442, 0x8442, 0x8442, 0x8442, 0x8442, 0x8442,
+	0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000,
+	0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000,
+	0x8000, 0x8000, 0x8000, 0x9000, 0x9111, 0x9000, 0x9000, 0x80000090,
+	0x8000, 0x8000, 0x8000, 0x9000, 0x9111, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x80000094, 0x9000, 0x40000098, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9111, 0x9000, 0x9111, 0x9111, 0x9111, 0x400000a0,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x800000a8, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x800000ac, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x800000ac, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x800000b0, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x8000,
+}
+
+var table2 = [256]uint32{
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x0, 0x0, 0x0, 0x0,
+	0x80000058, 0x0, 0x0, 0x0, 0x80000058, 0x80000058, 0x0, 0x80000058,
+	0x0, 0x0, 0x0, 0x0, 0x9000, 0x9000, 0x9000, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0, 0x0, 0x0, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x9000, 0x9000, 0x0, 0x0, 0x0, 0x0, 0x800000b4, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+}
+
+var table3 = [256]uint32{
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x9111,
+	0x0, 0x0, 0x0, 0x0, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x800000b8, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x9111, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x800000b8,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+}
+
+var table4 = [256]uint32{
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x800000bc, 0x800000bc, 0x800000bc, 0x800000c0, 0x800000bc,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000bc, 0x800000bc, 0x800000c4, 0x800000bc, 0x800000c4, 0x800000c4, 0x800000bc, 0x800000bc,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x800000bc, 0x800000bc, 0x0, 0x800000bc, 0x800000bc, 0x800000bc, 0x800000bc,
+	0x0, 0x0, 0x800000bc, 0x800000bc, 0x0, 0x0, 0x0, 0x0,
+	0x800000bc, 0x9000, 0x800000c8, 0x800000c8, 0x800000bc, 0x800000bc, 0x800000bc, 0x800000bc,
+	0x9000, 0x9000, 0x9000, 0x800000c0, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x800000b4,
+	0x800000cc, 0x800000d0, 0x800000d0, 0x800000d4, 0x80000058, 0x80000058, 0x80000058, 0x800000d8,
+	0x0, 0x0, 0x0, 0x0, 0x800000a8, 0x800000a8, 0x800000b4, 0x800000b4,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000bc, 0x800000bc, 0x800000dc, 0x800000dc, 0x0, 0x0, 0x0, 0x0,
+	0x800000bc, 0x800000bc, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x800000e8, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x9111, 0x0, 0x800000b8, 0x800000b8, 0x800000ec, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000a8, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x800000ac, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x800000b0, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0,
+}
+
+var table5 = [256]uint32{
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x0, 0x0, 0x80000058, 0x0, 0x0, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x0, 0x80000058, 0x80000058, 0x80000058, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x0, 0x0, 0x0, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x80000058, 0x80000058, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x80000058, 0x0, 0x80000058, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0, 0x0, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x800000f0, 0x800000fc, 0x0, 0x80000100, 0x800000b0, 0x9000,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+}
+
+var table6 = [256]uint32{
+	0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x800000b8, 0x800000b8, 0x800000b8, 0x0,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8,
+	0x0, 0x0, 0x0, 0x0, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8,
+	0x800000b8, 0x800000b8, 0x0, 0x0, 0x0, 0x800000b8, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x0, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x800000b8, 0x0, 0x800000b8, 0x0,
+	0x0, 0x0, 0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x0, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x800000b8,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x80000104, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+}
+
+var table7 = [256]uint32{
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x80000108, 0x80000108, 0x80000108,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x80000108, 0x80000108,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x80000108, 0x80000108, 0x80000108,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x80000108, 0x80000108,
+	0x0, 0x0, 0x80000108, 0x80000108, 0x0, 0x0, 0x80000108, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x80000108, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x80000108, 0x80000108, 0x80000108, 0x80000108, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x80000108, 0x80000108, 0x80000108, 0x80000108,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x80000108, 0x80000108, 0x80000108, 0x80000108,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+}
+
+var table8 = [256]uint32{
+	0x0, 0x80000114, 0x80000120, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x80000124, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000f0, 0x800000f0, 0x800000f0, 0x800000f0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000f0, 0x800000f0, 0x800000f0, 0x800000f0, 0x800000f0, 0x800000f0, 0x800000f0, 0x800000f0,
+	0x800000f0, 0x800000f0, 0x800000f0, 0x800000f0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x800000f0, 0x800000f0, 0x800000f0, 0x0, 0x0, 0x800000f0, 0x800000f0,
+	0x0, 0x0, 0x0, 0x800000f0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x800000f0, 0x800000f0, 0x800000f0, 0x0, 0x0, 0x800000f0, 0x800000f0,
+	0x0, 0x0, 0x0, 0x800000f0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x800000f0, 0x800000f0, 0x800000f0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+}
+
+var table9 = [256]uint32{
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x80000128, 0x0, 0x80000134, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+}
+
+var table10 = [256]uint32{
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x800000bc, 0x800000bc, 0x800000bc, 0x800000c0, 0x800000bc,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000bc, 0x800000bc, 0x800000c4, 0x800000bc, 0x800000c4, 0x800000c4, 0x800000bc, 0x800000bc,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x9000, 0x0, 0x0, 0x800000bc, 0x800000bc, 0x800000bc, 0x800000bc,
+	0x9000, 0x9000, 0x9000, 0x800000c0, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x800000ac,
+	0x800000cc, 0x800000d0, 0x80000140, 0x800000d4, 0x80000058, 0x80000058, 0x80000058, 0x0,
+	0x9000, 0x9000, 0x800000ac, 0x800000ac, 0x0, 0x0, 0x800000b4, 0x800000ac,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x9111, 0x0, 0x800000b8, 0x800000b8, 0x800000ec, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x800000ac, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0,
+}
+
+var table11 = [256]uint32{
+	0x80000058, 0x0, 0x0, 0x0, 0x80000058, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x80000058, 0x80000058, 0x80000058, 0x0, 0x0,
+	0x800000b4, 0x800000b4, 0x800000b4, 0x800000b4, 0x800000b4, 0x800000b4, 0x80000058, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x800000b4, 0x800000b4, 0x800000b4, 0x800000b4, 0x800000b4, 0x800000b4, 0x800000b4, 0x800000b4,
+	0x800000b4, 0x800000b4, 0x800000b4, 0x80000058, 0x80000058, 0x80000058, 0x0, 0x0,
+	0x800000b4, 0x800000b4, 0x800000b4, 0x800000b4, 0x800000b4, 0x800000b4, 0x80000058, 0x80000058,
+	0x800000b4, 0x800000b4, 0x800000b4, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x0, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x0, 0x0, 0x0, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x80000058, 0x80000058, 0x80000058, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x0, 0x0, 0x80000058, 0x0, 0x0, 0x0, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0, 0x80000058, 0x0, 0x0,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0, 0x0, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0, 0x0, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x0, 0x0, 0x0, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x80000058,
+	0x0, 0x0, 0x0, 0x0, 0x80000058, 0x0, 0x8000014c, 0x8000014c,
+	0x80000058, 0x0, 0x80000058, 0x80000058, 0x80000058, 0x80000058, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+}
+
+var table12 = [256]uint32{
+	0x800000b8, 0x800000b8, 0x0, 0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x0, 0x0, 0x800000b8,
+	0x0, 0x0, 0x0, 0x0, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x800000b8, 0x800000b8, 0x800000b8,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x800000b8, 0x800000b8, 0x800000b8,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8, 0x0, 0x0, 0x800000b8, 0x800000b8,
+	0x0, 0x0, 0x800000b8, 0x800000b8, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x800000b8, 0x800000b8, 0x0, 0x0, 0x800000b8, 0x800000b8, 0x800000b8, 0x800000b8,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x800000b8, 0x800000b8,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+}
+
+var table13 = [256]uint32{
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x8000, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x8000, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x8000, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x8000, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x0, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x0, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x0, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x0, 0x8000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x9000, 0x9000, 0x0, 0x0, 0x0, 0x0,
+	0x8424, 0x9424, 0x8111, 0x9111, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111,
+	0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111,
+	0x9111, 0x9424, 0x9111, 0x9111, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x40000000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x8646, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0xa000, 0xa000, 0xa000, 0xa000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8111, 0x8424, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111,
+	0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424,
+	0x40000008, 0x40000008, 0x8222, 0x8000, 0x9000, 0x9000, 0x40000010, 0x40000150,
+	0x8333, 0x8000, 0x8222, 0x8000, 0x8000, 0x8111, 0x8000, 0x8000,
+	0x40000020, 0x40000020, 0x40000020, 0x40000020, 0x8111, 0x8111, 0x8000, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111,
+	0x8424, 0x8424, 0x8646, 0x8111, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x0, 0x8000, 0x0, 0x0, 0x8000, 0x8000, 0x40000028, 0x40000158,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x40000038, 0x40000040,
+}
+
+var table14 = [256]uint32{
+	0x40000040, 0x40000048, 0x9000, 0x9000, 0x0, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x0, 0x8000, 0x0, 0x40000050, 0x8000, 0x9111,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0xd000, 0xd000, 0xd000, 0xd000, 0xd000, 0x0, 0xd000, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x40000000, 0x8000,
+	0x8000, 0x8000, 0x8000, 0x0, 0x8000, 0x8000, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x80000058, 0x80000058, 0x9000, 0x9000,
+	0x9111, 0x4000005c, 0x4000005c, 0x80000074, 0x9000, 0x9000, 0x9000, 0x8000,
+	0x80000080, 0x9000, 0x40000000, 0x40000000, 0x80000084, 0x80000084, 0x9000, 0x9000,
+	0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424,
+	0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424,
+	0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000,
+	0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000,
+	0x8000, 0x8000, 0x8000, 0x9000, 0x9111, 0x9000, 0x9000, 0x80000090,
+	0x8000, 0x8000, 0x8000, 0x9000, 0x9111, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x80000160, 0x9000, 0x40000098, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9111, 0x9000, 0x9111, 0x9111, 0x9111, 0x400000a0,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x800000a8, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x800000ac, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x800000ac, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x800000b0, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x8000,
+}
+
+var table15 = [256]uint32{
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x8111, 0x8424, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x0, 0x0, 0x0, 0x9000, 0x0, 0x0, 0x0, 0x0,
+	0x8424, 0x9424, 0x8111, 0x9111, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111,
+	0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111,
+	0x9111, 0x9424, 0x0, 0x9111, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x40000000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x0, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0xa000, 0xa000, 0xa000, 0xa000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8111, 0x8424, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111,
+	0x8824, 0x8824, 0x8824, 0x8824, 0x8824, 0x8824, 0x8824, 0x8824,
+	0x40000008, 0x40000008, 0x8222, 0x8000, 0x0, 0x0, 0x40000010, 0x40000164,
+	0x8333, 0x8000, 0x8222, 0x8000, 0x8000, 0x8111, 0x0, 0x8000,
+	0x40000020, 0x40000020, 0x40000020, 0x40000020, 0x0, 0x0, 0x8000, 0x8000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111, 0x8111,
+	0x8444, 0x8444, 0x0, 0x8111, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x0, 0x8000, 0x0, 0x0, 0x8000, 0x8000, 0x40000028, 0x40000158,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x40000038, 0x40000040,
+}
+
+var table16 = [256]uint32{
+	0x40000040, 0x9000, 0x9000, 0x9000, 0x0, 0x8000, 0x8000, 0x8000,
+	0x8000, 0x8000, 0x0, 0x8000, 0x0, 0x40000050, 0x8000, 0x9111,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0xd000, 0xd000, 0xd000, 0xd000, 0x0, 0x0, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x40000000, 0x8000,
+	0x8000, 0x8000, 0x8000, 0x0, 0x8000, 0x8000, 0x0, 0x0,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x80000058, 0x80000058, 0x9000, 0x9000,
+	0x9111, 0x4000005c, 0x4000005c, 0x80000074, 0x9000, 0x9000, 0x9000, 0x8000,
+	0x80000080, 0x9000, 0x40000000, 0x40000000, 0x80000084, 0x80000084, 0x9000, 0x9000,
+	0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424,
+	0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424, 0x8424,
+	0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000,
+	0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000, 0x40000000,
+	0x8000, 0x8000, 0x8000, 0x9000, 0x9111, 0x9000, 0x9000, 0x80000090,
+	0x8000, 0x8000, 0x8000, 0x9000, 0x9111, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x80000160, 0x9000, 0x40000098, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9111, 0x9000, 0x9111, 0x9111, 0x9111, 0x400000a0,
+	0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000, 0x8000,
+	0x800000a8, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x800000ac, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x800000ac, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x800000b0, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000,
+	0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x9000, 0x8000,
+}
+
+// tables is indexed by mode, encoding space and opcode map.
+var tables = [3][4][numMaps]*[256]uint32{
+	{ // 16-bit
+		{&table0, &table1, &table2, &table3, nil, nil, nil, nil, nil, nil, nil},
+		{nil, &table4, &table5, &table6, nil, nil, nil, nil, nil, nil, nil},
+		{nil, nil, nil, nil, nil, nil, nil, nil, &table7, &table8, &table9},
+		{nil, &table10, &table11, &table12, nil, nil, nil, nil, nil, nil, nil},
+	},
+	{ // 32-bit
+		{&table13, &table14, &table2, &table3, nil, nil, nil, nil, nil, nil, nil},
+		{nil, &table4, &table5, &table6, nil, nil, nil, nil, nil, nil, nil},
+		{nil, nil, nil, nil, nil, nil, nil, nil, &table7, &table8, &table9},
+		{nil, &table10, &table11, &table12, nil, nil, nil, nil, nil, nil, nil},
+	},
+	{ // 64-bit
+		{&table15, &table16, &table2, &table3, nil, nil, nil, nil, nil, nil, nil},
+		{nil, &table4, &table5, &table6, nil, nil, nil, nil, nil, nil, nil},
+		{nil, nil, nil, nil, nil, nil, nil, nil, &table7, &table8, &table9},
+		{nil, &table10, &table11, &table12, nil, nil, nil, nil, nil, nil, nil},
+	},
+}
@@ -0,0 +1,87 @@
+package x86db
+
+import (
+	"sort"
+	"strings"
+)
+
+// index holds the lookup tables built when opening the DB.
+type index struct {
+	mnemonics   []string
+	byMnemonic  map[string]InstructionSlice
+	byFoldedKey map[string]InstructionSlice
+	byExtension map[Extension]InstructionSlice
+	// byGroup is indexed by group ordinal. Instructions of a group are
+	// contiguous in the DB so entries share the DB storage.
+	byGroup []InstructionSlice
+	// byOpcode indexes instructions by their first opcode byte, see
+	// MatchBytes.
+	byOpcode map[opcodeKey][]*Instruction
+	// waitOnly are the instructions encoded as the WAIT byte alone.
+	waitOnly []*Instruction
+}
+
+// buildIndex builds the instruction lookup tables.
+func (db *DB) buildIndex() {
+	idx := &db.index
+	idx.mnemonics = nil
+	idx.byMnemonic = make(map[string]InstructionSlice)
+	idx.byFoldedKey = make(map[string]InstructionSlice)
+	idx.byExtension = make(map[Extension]InstructionSlice)
+	idx.byGroup = make([]InstructionSlice, len(db.groups)+1)
+
+	start := 0
+	for i, insn := range db.Instructions {
+		if _, ok := idx.byMnemonic[insn.Name]; !ok {
+			idx.mnemonics = append(idx.mnemonics, insn.Name)
+		}
+		idx.byMnemonic[insn.Name] = append(idx.byMnemonic[insn.Name], insn)
+
+		folded := strings.ToUpper(insn.Name)
+		idx.byFoldedKey[folded] = append(idx.byFoldedKey[folded], insn)
+
+		// ExtensionBase isn't part of ExtensionList.
+		if insn.Extensions.IsEmpty() {
+			idx.byExtension[ExtensionBase] = append(idx.byExtension[ExtensionBase], insn)
+		}
+		for _, e := range insn.Extensions.Extensions() {
+			idx.byExtension[e] = append(idx.byExtension[e], insn)
+		}
+
+		last := i == len(db.Instructions)-1
+		if last || db.Instructions[i+1].Group.Ordinal != insn.Group.Ordinal {
+			idx.byGroup[insn.Group.Ordinal] = db.Instructions[start : i+1 : i+1]
+			start = i + 1
+		}
+	}
+
+	sort.Strings(idx.mnemonics)
+	idx.buildOpcodeIndex(db.Instructions)
+
+	// Callers appending to the returned slices mustn't step on each other.
+	for k, v := range idx.byMnemonic {
+		idx.byMnemonic[k] = v[:len(v):len(v)]
+	}
+	for k, v := range idx.byFoldedKey {
+		idx.byFoldedKey[k] = v[:len(v):len(v)]
+	}
+	for k, v := range idx.byExtension {
+		idx.byExtension[k] = v[:len(v):len(v)]
+	}
+}
+
+// Lookup returns the list of instructions with the given mnemonic. The slices
+// returned by the lookup functions are shared and shouldn't be modified.
+func (db *DB) Lookup(mnemonic string) InstructionSlice {
+	return db.index.byMnemonic[mnemonic]
+}
+
+// LookupFold is a case insensitive version of Lookup.
+func (db *DB) LookupFold(mnemonic string) InstructionSlice {
+	return db.index.byFoldedKey[strings.ToUpper(mnemonic)]
+}
+
+// Mnemonics returns the sorted list of instruction mnemonics.
+func (db *DB) Mnemonics() []string {
+	return db.index.mnemonics
+}
@@ -0,0 +1,58 @@
+package x86db
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+
+	adds := db.Lookup("ADDPS")
+	assert.NotEqual(t, 0, len(adds))
+	for _, insn := range adds {
+		assert.Equal(t, "ADDPS", insn.Name)
+	}
+	assert.Equal(t, adds, db.LookupFold("addps"))
+	assert.Equal(t, adds, db.LookupFold("AddPs"))
+	assert.Nil(t, db.Lookup("addps"))
+	assert.Nil(t, db.Lookup("FOOBAR"))
+
+	mnemonics := db.Mnemonics()
+	assert.True(t, sort.StringsAreSorted(mnemonics))
+	total := 0
+	for _, m := range mnemonics {
+		total += len(db.Lookup(m))
+	}
+	assert.Equal(t, len(db.Instructions), total)
+
+	// Opening the DB again rebuilds the index from scratch.
+	assert.Nil(t, db.Open())
+	assert.Equal(t, mnemonics, db.Mnemonics())
+}
+
+func TestIndexMatchesScan(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+
+	extensions := []Extension{ExtensionBase}
+	for _, info := range ExtensionList {
+		extensions = append(extensions, info.Extension)
+	}
+	for _, e := range extensions {
+		scan := db.Instructions.Where(func(insn Instruction) bool {
+			return insn.Extensions.Has(e)
+		})
+		assert.Equal(t, scan, db.FindByExtension(e), e.String())
+	}
+
+	for _, g := range db.Groups() {
+		scan := db.Instructions.Where(func(insn Instruction) bool {
+			return insn.Group.Ordinal == g.Ordinal
+		})
+		assert.Equal(t, scan, db.FindByGroup(g), g.Title)
+	}
+}
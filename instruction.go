@@ -63,6 +63,167 @@ func opSizeFromString(name string) (OpSize, error) {
 	return OpSize(0), fmt.Errorf("no OpSize with name '%s'", name)
 }
 
+// Attr holds the attribute flags of an instruction.
+type Attr uint32
+
+const (
+	AttrLOCK Attr = 1 << iota
+	AttrND
+	AttrUNDOC
+	AttrPRIV
+	AttrPROT
+	AttrNOLONG
+	AttrLONG
+	AttrAMD
+	AttrCYRIX
+	AttrSMM
+	AttrBND
+	AttrHLE
+	AttrNOHLE
+	AttrMIB
+)
+
+type attrInfo struct {
+	flag Attr
+	name string
+	help string
+}
+
+var attrTab = []attrInfo{
+	{AttrLOCK, "LOCK", "Lockable"},
+	{AttrND, "ND", "Not for disassembly"},
+	{AttrUNDOC, "UNDOC", "Undocumented"},
+	{AttrPRIV, "PRIV", "Privileged"},
+	{AttrPROT, "PROT", "Protected mode only"},
+	{AttrNOLONG, "NOLONG", "Not available in long mode"},
+	{AttrLONG, "LONG", "Long mode only"},
+	{AttrAMD, "AMD", "AMD specific"},
+	{AttrCYRIX, "CYRIX", "Cyrix specific"},
+	{AttrSMM, "SMM", "Needs System Management Mode"},
+	{AttrBND, "BND", "BND (F2) prefix available"},
+	{AttrHLE, "HLE", "HLE prefixed"},
+	{AttrNOHLE, "NOHLE", "HLE prefixes forbidden"},
+	{AttrMIB, "MIB", "Disassemble with split effective address"},
+}
+
+// AttrFromString returns the attribute flag with the given name.
+func AttrFromString(name string) (Attr, error) {
+	for _, info := range attrTab {
+		if info.name == name {
+			return info.flag, nil
+		}
+	}
+
+	return Attr(0), fmt.Errorf("no Attr with name '%s'", name)
+}
+
+// Has returns true if all the flags in a2 are set in a.
+func (a Attr) Has(a2 Attr) bool {
+	return a&a2 == a2
+}
+
+// String implements the stringer interface for Attr. Flag names are comma
+// separated.
+func (a Attr) String() string {
+	var names []string
+	for _, info := range attrTab {
+		if a&info.flag != 0 {
+			names = append(names, info.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Modes is a set of processor execution modes, named after their default
+// operand size.
+type Modes uint8
+
+const (
+	Mode16 Modes = 1 << iota
+	Mode32
+	Mode64
+
+	ModesNone Modes = 0
+	ModesAll        = Mode16 | Mode32 | Mode64
+)
+
+var modeNames = []struct {
+	mode Modes
+	name string
+}{
+	{Mode16, "16"},
+	{Mode32, "32"},
+	{Mode64, "64"},
+}
+
+// ModesFromString parses a comma separated list of modes, eg. "32,64".
+func ModesFromString(str string) (Modes, error) {
+	var modes Modes
+
+next:
+	for _, name := range strings.Split(str, ",") {
+		for _, info := range modeNames {
+			if info.name == name {
+				modes |= info.mode
+				continue next
+			}
+		}
+		return ModesNone, fmt.Errorf("no Mode with name '%s'", name)
+	}
+
+	return modes, nil
+}
+
+// Has returns true if all the modes in m2 are in m.
+func (m Modes) Has(m2 Modes) bool {
+	return m&m2 == m2
+}
+
+// String implements the stringer interface for Modes.
+func (m Modes) String() string {
+	var names []string
+	for _, info := range modeNames {
+		if m&info.mode != 0 {
+			names = append(names, info.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// instructionModes computes the execution modes an instruction form can be
+// assembled in. x64 is set when the form has the X64 flag, nasm's shorthand for
+// LONG,X86_64.
+func instructionModes(x64 bool, attr Attr, encoding *Encoding, args []Operand) Modes {
+	modes := ModesAll
+
+	if attr.Has(AttrNOLONG) || encoding.AddressSize == Size16 {
+		modes &^= Mode64
+	}
+
+	longOnly := x64 || attr.Has(AttrLONG) ||
+		encoding.OperandSize == Size64 ||
+		encoding.AddressSize == Size64
+	// 64-bit general purpose registers need a REX prefix. The MMX forms are
+	// the only ones with o64nw available outside of 64-bit mode.
+	mmx := false
+	for _, arg := range args {
+		if arg.Class == RegisterClassGP && arg.Size == 64 {
+			longOnly = true
+		}
+		if arg.Class == RegisterClassMMX {
+			mmx = true
+		}
+	}
+	if encoding.OperandSize == Size64NoW && !mmx {
+		longOnly = true
+	}
+	if longOnly {
+		modes &= Mode64
+	}
+
+	return modes
+}
+
 type Extension int
 
 const (
@@ -99,6 +260,32 @@ const (
 	ExtensionAVX512BW
 	ExtensionAVX512IFMA
 	ExtensionAVX512VBMI
+	ExtensionAES
+	ExtensionCLMUL
+	ExtensionXOP
+	ExtensionFMA4
+	ExtensionLWP
+	ExtensionPKU
+	ExtensionSMX
+	ExtensionHLE
+	ExtensionPADLOCK
+	ExtensionXSAVE
+	ExtensionRDRAND
+	ExtensionRDSEED
+	ExtensionADX
+	Extension3DNOWEXT
+	ExtensionGEODE
+	ExtensionLZCNT
+	ExtensionPOPCNT
+	ExtensionMOVBE
+	ExtensionFSGSBASE
+	ExtensionF16C
+	ExtensionSMAP
+	ExtensionRDPID
+	ExtensionCLFLUSHOPT
+	ExtensionCLWB
+	ExtensionCLZERO
+	ExtensionHINTNOP
 )
 
 // ExtensionInfo stores metadata about an extension.
@@ -142,6 +329,32 @@ var ExtensionList = []ExtensionInfo{
 	{ExtensionAVX512BW, "AVX512BW", "AVX-512 Byte and Word"},
 	{ExtensionAVX512IFMA, "AVX512IFMA", "AVX-512 IFMA instructions"},
 	{ExtensionAVX512VBMI, "AVX512VBMI", "AVX-512 VBMI instructions"},
+	{ExtensionAES, "AES", "AES instructions"},
+	{ExtensionCLMUL, "CLMUL", "Carry-less multiplication (PCLMULQDQ)"},
+	{ExtensionXOP, "XOP", "AMD XOP"},
+	{ExtensionFMA4, "FMA4", "AMD 4-operand FMA"},
+	{ExtensionLWP, "LWP", "AMD Lightweight Profiling"},
+	{ExtensionPKU, "PKU", "Memory protection keys for userspace"},
+	{ExtensionSMX, "SMX", "Safer Mode Extensions"},
+	{ExtensionHLE, "HLE", "TSX Hardware Lock Elision"},
+	{ExtensionPADLOCK, "PADLOCK", "VIA PadLock security instructions"},
+	{ExtensionXSAVE, "XSAVE", "XSAVE family (extended state)"},
+	{ExtensionRDRAND, "RDRAND", "RDRAND"},
+	{ExtensionRDSEED, "RDSEED", "RDSEED"},
+	{ExtensionADX, "ADX", "Multi-precision add-carry (ADCX, ADOX)"},
+	{Extension3DNOWEXT, "3DNOWEXT", "AMD Enhanced 3DNow!"},
+	{ExtensionGEODE, "GEODE", "Geode (Cyrix) 3DNow! additions"},
+	{ExtensionLZCNT, "LZCNT", "LZCNT (AMD ABM)"},
+	{ExtensionPOPCNT, "POPCNT", "POPCNT"},
+	{ExtensionMOVBE, "MOVBE", "MOVBE"},
+	{ExtensionFSGSBASE, "FSGSBASE", "FS/GS base access"},
+	{ExtensionF16C, "F16C", "Half-precision conversions"},
+	{ExtensionSMAP, "SMAP", "Supervisor Mode Access Prevention"},
+	{ExtensionRDPID, "RDPID", "Read Processor ID"},
+	{ExtensionCLFLUSHOPT, "CLFLUSHOPT", "CLFLUSHOPT"},
+	{ExtensionCLWB, "CLWB", "Cache line write back"},
+	{ExtensionCLZERO, "CLZERO", "AMD CLZERO"},
+	{ExtensionHINTNOP, "HINTNOP", "Hinting NOPs (0F 18-1F)"},
 }
 
 func ExtensionFromString(name string) (Extension, error) {
@@ -154,9 +367,339 @@ func ExtensionFromString(name string) (Extension, error) {
 	return ExtensionBase, fmt.Errorf("no Extension with name '%s'", name)
 }
 
+// String implements the stringer interface for Extension.
+func (e Extension) String() string {
+	if e == ExtensionBase {
+		return "Base"
+	}
+	for _, info := range ExtensionList {
+		if info.Extension == e {
+			return info.Name
+		}
+	}
+	return fmt.Sprintf("Extension(%d)", int(e))
+}
+
+// ExtensionSet is a set of extensions. The zero value is the empty set, the
+// set of instructions part of the base instruction set.
+type ExtensionSet struct {
+	bits [2]uint64
+}
+
+// NewExtensionSet creates a set holding extensions. ExtensionBase is ignored.
+func NewExtensionSet(extensions ...Extension) ExtensionSet {
+	var s ExtensionSet
+	for _, e := range extensions {
+		s.Add(e)
+	}
+	return s
+}
+
+// ExtensionSetFromString parses a comma separated list of extension names.
+func ExtensionSetFromString(str string) (ExtensionSet, error) {
+	var s ExtensionSet
+	for _, name := range strings.Split(str, ",") {
+		e, err := ExtensionFromString(name)
+		if err != nil {
+			return s, err
+		}
+		s.Add(e)
+	}
+	return s, nil
+}
+
+// Add adds e to the set.
+func (s *ExtensionSet) Add(e Extension) {
+	if e == ExtensionBase {
+		return
+	}
+	s.bits[e/64] |= 1 << (uint(e) % 64)
+}
+
+// Has returns true if e is part of the set. ExtensionBase is only part of
+// the empty set.
+func (s ExtensionSet) Has(e Extension) bool {
+	if e == ExtensionBase {
+		return s.IsEmpty()
+	}
+	return s.bits[e/64]&(1<<(uint(e)%64)) != 0
+}
+
+// HasAll returns true if all the extensions of other are in s.
+func (s ExtensionSet) HasAll(other ExtensionSet) bool {
+	return s.Intersect(other) == other
+}
+
+// IsEmpty returns true if the set has no extension.
+func (s ExtensionSet) IsEmpty() bool {
+	return s == ExtensionSet{}
+}
+
+// Union returns the extensions either in s or other.
+func (s ExtensionSet) Union(other ExtensionSet) ExtensionSet {
+	for i := range s.bits {
+		s.bits[i] |= other.bits[i]
+	}
+	return s
+}
+
+// Intersect returns the extensions both in s and other.
+func (s ExtensionSet) Intersect(other ExtensionSet) ExtensionSet {
+	for i := range s.bits {
+		s.bits[i] &= other.bits[i]
+	}
+	return s
+}
+
+// Extensions returns the extensions of the set in ExtensionList order.
+func (s ExtensionSet) Extensions() []Extension {
+	var extensions []Extension
+	for _, info := range ExtensionList {
+		if s.Has(info.Extension) {
+			extensions = append(extensions, info.Extension)
+		}
+	}
+	return extensions
+}
+
+// String implements the stringer interface for ExtensionSet. Extension names
+// are comma separated, in ExtensionList order.
+func (s ExtensionSet) String() string {
+	var names []string
+	for _, e := range s.Extensions() {
+		names = append(names, e.String())
+	}
+	return strings.Join(names, ",")
+}
+
+// CPULevel is the processor generation that introduced an instruction.
+// Levels are ordered, a processor supports the instructions of the levels
+// below its own.
+type CPULevel int
+
+const (
+	// CPULevelNone is used when insns.dat doesn't specify a level.
+	CPULevelNone CPULevel = iota
+	CPULevel8086
+	CPULevel186
+	CPULevel286
+	CPULevel386
+	CPULevel486
+	CPULevelPENT
+	CPULevelP6
+	CPULevelKATMAI
+	CPULevelWILLAMETTE
+	CPULevelPRESCOTT
+	CPULevelX64
+	CPULevelNEHALEM
+	CPULevelWESTMERE
+	CPULevelSANDYBRIDGE
+	CPULevelFUTURE
+	CPULevelIA64
+)
+
+// CPULevelInfo stores metadata about a CPU level.
+type CPULevelInfo struct {
+	Level CPULevel
+	Name  string
+	Help  string
+}
+
+// CPULevelList is the list of known CPU levels, in ascending order.
+var CPULevelList = []CPULevelInfo{
+	{CPULevel8086, "8086", "8086"},
+	{CPULevel186, "186", "80186"},
+	{CPULevel286, "286", "80286"},
+	{CPULevel386, "386", "80386"},
+	{CPULevel486, "486", "80486"},
+	{CPULevelPENT, "PENT", "Pentium"},
+	{CPULevelP6, "P6", "Pentium Pro"},
+	{CPULevelKATMAI, "KATMAI", "Pentium III"},
+	{CPULevelWILLAMETTE, "WILLAMETTE", "Pentium 4"},
+	{CPULevelPRESCOTT, "PRESCOTT", "Pentium 4 with SSE3"},
+	{CPULevelX64, "X64", "x86-64"},
+	{CPULevelNEHALEM, "NEHALEM", "Nehalem"},
+	{CPULevelWESTMERE, "WESTMERE", "Westmere"},
+	{CPULevelSANDYBRIDGE, "SANDYBRIDGE", "Sandy Bridge"},
+	{CPULevelFUTURE, "FUTURE", "Future processors"},
+	{CPULevelIA64, "IA64", "IA-64 (x86 emulation)"},
+}
+
+// cpuLevelAliases are alternate names for CPU levels found in insns.dat.
+var cpuLevelAliases = map[string]CPULevel{
+	"X86_64": CPULevelX64,
+}
+
+// CPULevelFromString returns the CPU level with the given name.
+func CPULevelFromString(name string) (CPULevel, error) {
+	for _, info := range CPULevelList {
+		if info.Name == name {
+			return info.Level, nil
+		}
+	}
+
+	if level, ok := cpuLevelAliases[name]; ok {
+		return level, nil
+	}
+
+	return CPULevelNone, fmt.Errorf("no CPULevel with name '%s'", name)
+}
+
+// String implements the stringer interface for CPULevel.
+func (l CPULevel) String() string {
+	for _, info := range CPULevelList {
+		if info.Level == l {
+			return info.Name
+		}
+	}
+	return ""
+}
+
+// Group is a section of insns.dat, as introduced by a ";#" comment. Those
+// sections are the ones used in the nasm documentation.
+type Group struct {
+	Title string
+	// Ordinal is the position of the group in insns.dat, starting at 1.
+	// Instructions listed before the first section have a zero Group.
+	Ordinal int
+}
+
+// String implements the stringer interface for Group.
+func (g Group) String() string {
+	return g.Title
+}
+
+// TupleType is the EVEX tuple type of an AVX-512 instruction. It determines
+// the N factor of compressed 8-bit displacements (disp8*N).
+type TupleType int
+
+const (
+	TupleNone TupleType = iota
+	TupleFV
+	TupleHV
+	TupleFVM
+	TupleHVM
+	TupleQVM
+	TupleOVM
+	TupleM128
+	TupleDUP
+	TupleT1S
+	TupleT1S8
+	TupleT1S16
+	TupleT1F32
+	TupleT1F64
+	TupleT2
+	TupleT4
+	TupleT8
+)
+
+type tupleTypeInfo struct {
+	tuple TupleType
+	name  string
+	help  string
+}
+
+var tupleTypeTab = []tupleTypeInfo{
+	{TupleFV, "fv", "Full vector"},
+	{TupleHV, "hv", "Half vector"},
+	{TupleFVM, "fvm", "Full vector memory"},
+	{TupleHVM, "hvm", "Half vector memory"},
+	{TupleQVM, "qvm", "Quarter vector memory"},
+	{TupleOVM, "ovm", "Oct vector memory"},
+	{TupleM128, "m128", "128 bits of memory"},
+	{TupleDUP, "dup", "Duplicate (MOVDDUP)"},
+	{TupleT1S, "t1s", "Tuple1 scalar, 32 or 64 bits depending on EVEX.W"},
+	{TupleT1S8, "t1s8", "Tuple1 scalar, 8 bits"},
+	{TupleT1S16, "t1s16", "Tuple1 scalar, 16 bits"},
+	{TupleT1F32, "t1f32", "Tuple1 fixed, 32 bits"},
+	{TupleT1F64, "t1f64", "Tuple1 fixed, 64 bits"},
+	{TupleT2, "t2", "Tuple2"},
+	{TupleT4, "t4", "Tuple4"},
+	{TupleT8, "t8", "Tuple8"},
+}
+
+func tupleTypeFromString(name string) (TupleType, error) {
+	for _, info := range tupleTypeTab {
+		if info.name == name {
+			return info.tuple, nil
+		}
+	}
+
+	return TupleNone, fmt.Errorf("no TupleType with name '%s'", name)
+}
+
+// String implements the stringer interface for TupleType.
+func (t TupleType) String() string {
+	for _, info := range tupleTypeTab {
+		if info.tuple == t {
+			return info.name
+		}
+	}
+	return ""
+}
+
+// Disp8N returns the scaling factor N applied to 8-bit displacements of
+// EVEX-encoded memory operands. vectorLength is in bits, w is the value of
+// EVEX.W and broadcast indicates whether embedded broadcast is in use. 1 is
+// returned when displacements aren't compressed.
+func (t TupleType) Disp8N(vectorLength int, w bool, broadcast bool) int {
+	elem := 4
+	if w {
+		elem = 8
+	}
+	vl := vectorLength / 8
+
+	switch t {
+	case TupleFV:
+		if broadcast {
+			return elem
+		}
+		return vl
+	case TupleHV:
+		if broadcast {
+			return elem
+		}
+		return vl / 2
+	case TupleFVM:
+		return vl
+	case TupleHVM:
+		return vl / 2
+	case TupleQVM:
+		return vl / 4
+	case TupleOVM:
+		return vl / 8
+	case TupleM128:
+		return 16
+	case TupleDUP:
+		if vl == 16 {
+			return 8
+		}
+		return vl
+	case TupleT1S:
+		return elem
+	case TupleT1S8:
+		return 1
+	case TupleT1S16:
+		return 2
+	case TupleT1F32:
+		return 4
+	case TupleT1F64:
+		return 8
+	case TupleT2:
+		return 2 * elem
+	case TupleT4:
+		return 4 * elem
+	case TupleT8:
+		return 8 * elem
+	}
+
+	return 1
+}
+
 // Pattern is a Nasm pattern as found in insns.dat.
 //
 //   [operands: opcodes]
+//   [operands:tuple: opcodes]
 //
 // Operands describe the operands for this instruction:
 // r = register field in the modr/m
@@ -166,9 +709,12 @@ func ExtensionFromString(name string) (Extension, error) {
 // s = register field of is4/imz2 field
 // - = implicit (unencoded) operand
 // x = indeX register of mib
+//
+// TupleType is only present on EVEX-encoded instructions.
 type Pattern struct {
-	Operands string
-	Opcodes  []string
+	Operands  string
+	TupleType TupleType
+	Opcodes   []string
 }
 
 func patternFromString(str string) (*Pattern, error) {
@@ -180,20 +726,92 @@ func patternFromString(str string) (*Pattern, error) {
 		}, nil
 	}
 
-	return &Pattern{
+	pattern := &Pattern{
 		Operands: str[:sep],
-		Opcodes:  strings.Fields(str[sep+1:]),
-	}, nil
+	}
+
+	str = str[sep+1:]
+	sep = strings.Index(str, ":")
+	if sep >= 0 {
+		name := strings.TrimSpace(str[:sep])
+		tuple, err := tupleTypeFromString(name)
+		if err != nil {
+			return nil, &tokenError{name, err}
+		}
+		pattern.TupleType = tuple
+		str = str[sep+1:]
+	}
+
+	pattern.Opcodes = strings.Fields(str)
+
+	return pattern, nil
+}
+
+// Disp8N returns the disp8*N scaling factor of the pattern, see
+// TupleType.Disp8N. The vector length and EVEX.W are taken from the evex
+// opcode, the vector length defaulting to 128 bits.
+func (p *Pattern) Disp8N(broadcast bool) int {
+	if p.TupleType == TupleNone {
+		return 1
+	}
+
+	vectorLength := 128
+	w := false
+	for _, opcode := range p.Opcodes {
+		if !strings.HasPrefix(opcode, "evex.") {
+			continue
+		}
+		for _, field := range strings.Split(opcode, ".") {
+			switch field {
+			case "256", "l1":
+				vectorLength = 256
+			case "512":
+				vectorLength = 512
+			case "w1":
+				w = true
+			}
+		}
+	}
+
+	return p.TupleType.Disp8N(vectorLength, w, broadcast)
+}
+
+func (p *Pattern) hasOpcode(opcode string) bool {
+	for _, o := range p.Opcodes {
+		if o == opcode {
+			return true
+		}
+	}
+	return false
 }
 
 // +gen slice:"Where"
 type Instruction struct {
-	Name      string
-	Operands  []string
-	Pattern   Pattern
-	Flags     string
-	Extension Extension
-	OpSize    OpSize
+	Name     string
+	Operands []string
+	// Args holds the parsed Operands. A far "seg:offset" operand is split
+	// in two so Args lines up with the operand letters of Pattern.
+	Args    []Operand
+	Pattern Pattern
+	// Encoding is the parsed code string of Pattern.
+	Encoding   Encoding
+	Flags      string
+	Extensions ExtensionSet
+	CPULevel   CPULevel
+	OpSize     OpSize
+	Attr       Attr
+	// Group is the insns.dat section the instruction is listed in.
+	Group Group
+	// Modes are the execution modes the instruction can be assembled in.
+	Modes Modes
+	// Template is the condition code template, eg. "Jcc", the instruction
+	// has been expanded from. Condition is then its condition code,
+	// ConditionNone otherwise. See LoadOptions.ExpandConditions.
+	Template  *Instruction
+	Condition Condition
+	// File and Line locate the instruction definition.
+	File string
+	Line int
 }
 
 // String implements the stringer interface for Instruction
@@ -0,0 +1,83 @@
+package x86db
+
+import (
+	"sort"
+)
+
+// SortBy returns a new InstructionSlice sorted with less. The sort is stable.
+func (rcv InstructionSlice) SortBy(less func(Instruction, Instruction) bool) InstructionSlice {
+	result := make(InstructionSlice, len(rcv))
+	copy(result, rcv)
+	sort.SliceStable(result, func(i, j int) bool {
+		return less(result[i], result[j])
+	})
+	return result
+}
+
+// GroupBy groups the elements of the slice by the key returned by fn.
+func (rcv InstructionSlice) GroupBy(fn func(Instruction) string) map[string]InstructionSlice {
+	result := make(map[string]InstructionSlice)
+	for _, v := range rcv {
+		key := fn(v)
+		result[key] = append(result[key], v)
+	}
+	return result
+}
+
+// Distinct returns a new InstructionSlice holding the first element for each
+// key returned by fn, eg. one instruction per mnemonic.
+func (rcv InstructionSlice) Distinct(fn func(Instruction) string) (result InstructionSlice) {
+	seen := make(map[string]bool)
+	for _, v := range rcv {
+		key := fn(v)
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// Count returns the number of elements for which fn returns true.
+func (rcv InstructionSlice) Count(fn func(Instruction) bool) (result int) {
+	for _, v := range rcv {
+		if fn(v) {
+			result++
+		}
+	}
+	return result
+}
+
+// Any returns true if fn returns true for any element of the slice.
+func (rcv InstructionSlice) Any(fn func(Instruction) bool) bool {
+	for _, v := range rcv {
+		if fn(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// All returns true if fn returns true for all the elements of the slice.
+func (rcv InstructionSlice) All(fn func(Instruction) bool) bool {
+	for _, v := range rcv {
+		if !fn(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Mnemonics returns the sorted list of the distinct mnemonics of the slice.
+func (rcv InstructionSlice) Mnemonics() []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, v := range rcv {
+		if !seen[v.Name] {
+			seen[v.Name] = true
+			result = append(result, v.Name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,47 @@
+package x86db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstructionSliceHelpers(t *testing.T) {
+	insns := InstructionSlice{
+		{Name: "SUB", CPULevel: CPULevel8086},
+		{Name: "ADD", CPULevel: CPULevel386},
+		{Name: "SUB", CPULevel: CPULevel386},
+		{Name: "ADC", CPULevel: CPULevel8086},
+	}
+	is386 := func(insn Instruction) bool {
+		return insn.CPULevel == CPULevel386
+	}
+	name := func(insn Instruction) string {
+		return insn.Name
+	}
+
+	sorted := insns.SortBy(func(a, b Instruction) bool {
+		return a.Name < b.Name
+	})
+	assert.Equal(t, []string{"ADC", "ADD", "SUB", "SUB"}, []string{
+		sorted[0].Name, sorted[1].Name, sorted[2].Name, sorted[3].Name})
+	// stable and the receiver is left untouched
+	assert.Equal(t, CPULevel8086, sorted[2].CPULevel)
+	assert.Equal(t, "SUB", insns[0].Name)
+
+	groups := insns.GroupBy(name)
+	assert.Equal(t, 3, len(groups))
+	assert.Equal(t, 2, len(groups["SUB"]))
+
+	distinct := insns.Distinct(name)
+	assert.Equal(t, 3, len(distinct))
+	assert.Equal(t, CPULevel8086, distinct[0].CPULevel)
+
+	assert.Equal(t, 2, insns.Count(is386))
+	assert.True(t, insns.Any(is386))
+	assert.False(t, insns.All(is386))
+	assert.True(t, insns.Where(is386).All(is386))
+	assert.False(t, InstructionSlice{}.Any(is386))
+
+	assert.Equal(t, []string{"ADC", "ADD", "SUB"}, insns.Mnemonics())
+}
@@ -0,0 +1,119 @@
+package x86db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisp8N(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		broadcast bool
+		n         int
+	}{
+		{"rvm:fv: evex.nds.512.66.0f.w1 58 /r", false, 64},
+		{"rvm:fv: evex.nds.512.66.0f.w1 58 /r", true, 8},
+		{"rvm:fv: evex.nds.128.0f.w0 58 /r", true, 4},
+		{"rm:hv: evex.256.0f.w0 5a /r", false, 16},
+		{"rm:t1s: evex.lig.f2.0f.w1 10 /r", false, 8},
+		{"mri:t1s: evex.128.66.0f3a.wig 17 /r ib", false, 4},
+		{"rm:t1s8: evex.128.66.0f38.w0 78 /r", false, 1},
+		{"rm:t4: evex.512.66.0f38.w1 1b /r", false, 32},
+		{"rm:dup: evex.128.f2.0f.w1 12 /r", false, 8},
+		{"rm:ovm: evex.512.f3.0f38.w0 31 /r", false, 8},
+		{"rm: vex.128.66.0f 6f /r", false, 1},
+	}
+
+	for _, test := range tests {
+		p, err := patternFromString(test.pattern)
+		assert.Nil(t, err)
+		assert.Equal(t, test.n, p.Disp8N(test.broadcast), test.pattern)
+	}
+}
+
+func TestExtensionSet(t *testing.T) {
+	var empty ExtensionSet
+	assert.True(t, empty.IsEmpty())
+	assert.True(t, empty.Has(ExtensionBase))
+	assert.Equal(t, "", empty.String())
+
+	vl := NewExtensionSet(ExtensionAVX512VL)
+	bw := NewExtensionSet(ExtensionAVX512BW)
+	both := vl.Union(bw)
+
+	assert.False(t, both.Has(ExtensionBase))
+	assert.True(t, both.Has(ExtensionAVX512VL))
+	assert.True(t, both.Has(ExtensionAVX512BW))
+	assert.False(t, both.Has(ExtensionAVX512DQ))
+	assert.True(t, both.HasAll(vl))
+	assert.False(t, vl.HasAll(both))
+	assert.Equal(t, vl, both.Intersect(vl))
+	assert.True(t, vl.Intersect(bw).IsEmpty())
+	assert.Equal(t, "AVX512VL,AVX512BW", both.String())
+	assert.Equal(t, "AVX512VL,AVX512BW", bw.Union(vl).String())
+
+	parsed, err := ExtensionSetFromString("AVX512BW,AVX512VL")
+	assert.Nil(t, err)
+	assert.Equal(t, both, parsed)
+
+	_, err = ExtensionSetFromString("AVX512BW,FOO")
+	assert.NotNil(t, err)
+}
+
+func TestCPULevelFromString(t *testing.T) {
+	l, err := CPULevelFromString("KATMAI")
+	assert.Nil(t, err)
+	assert.Equal(t, CPULevelKATMAI, l)
+	assert.Equal(t, "KATMAI", l.String())
+
+	l, err = CPULevelFromString("X86_64")
+	assert.Nil(t, err)
+	assert.Equal(t, CPULevelX64, l)
+
+	_, err = CPULevelFromString("Z80")
+	assert.NotNil(t, err)
+
+	for i := 1; i < len(CPULevelList); i++ {
+		assert.True(t, CPULevelList[i-1].Level < CPULevelList[i].Level)
+	}
+}
+
+func TestAttr(t *testing.T) {
+	a, err := AttrFromString("UNDOC")
+	assert.Nil(t, err)
+	assert.Equal(t, AttrUNDOC, a)
+
+	_, err = AttrFromString("SM")
+	assert.NotNil(t, err)
+
+	a = AttrLOCK | AttrPRIV
+	assert.True(t, a.Has(AttrLOCK))
+	assert.False(t, a.Has(AttrLOCK|AttrUNDOC))
+	assert.Equal(t, "LOCK,PRIV", a.String())
+}
+
+func TestModesFromString(t *testing.T) {
+	tests := []struct {
+		input  string
+		valid  bool
+		golden Modes
+	}{
+		{"64", true, Mode64},
+		{"16,32", true, Mode16 | Mode32},
+		{"16,32,64", true, ModesAll},
+		{"128", false, ModesNone},
+		{"", false, ModesNone},
+	}
+
+	for _, test := range tests {
+		modes, err := ModesFromString(test.input)
+		if !test.valid {
+			assert.NotNil(t, err)
+			continue
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, test.golden, modes)
+		assert.Equal(t, test.input, modes.String())
+	}
+}
@@ -0,0 +1,664 @@
+package x86db
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// MatchField is an encoding field checked when matching instruction bytes
+// against the instruction patterns.
+type MatchField uint32
+
+const (
+	// MatchWait is set when the instruction is preceded by a WAIT byte.
+	MatchWait MatchField = 1 << iota
+	// MatchPrefix is set when a mandatory prefix, its absence (np) or the
+	// VEX/XOP/EVEX pp field matched.
+	MatchPrefix
+	// MatchOperandSize is set when the 0x66 prefix or REX.W matched an
+	// operand size marker.
+	MatchOperandSize
+	// MatchAddressSize is set when the 0x67 prefix matched an address size
+	// marker.
+	MatchAddressSize
+	// MatchEscape is set when the 0f, 0f 38, 0f 3a escapes or the VEX, XOP
+	// and EVEX map matched.
+	MatchEscape
+	// MatchOpcode is set when the opcode bytes matched.
+	MatchOpcode
+	// MatchModRMReg is set when the reg field of the ModRM byte matched a
+	// /digit.
+	MatchModRMReg
+	// MatchModRMMod is set when the mod field of the ModRM byte matched a
+	// register only or memory only operand.
+	MatchModRMMod
+	// MatchW is set when the W bit of VEX, XOP or EVEX matched.
+	MatchW
+	// MatchL is set when the vector length matched.
+	MatchL
+	// MatchSuffix is set when the literal bytes after the ModRM byte or an
+	// immediate matched.
+	MatchSuffix
+	// MatchEVEXB is set when EVEX.b matched an operand with embedded
+	// broadcast, rounding or SAE.
+	MatchEVEXB
+)
+
+var matchFieldTab = []struct {
+	field MatchField
+	name  string
+}{
+	{MatchWait, "wait"},
+	{MatchPrefix, "prefix"},
+	{MatchOperandSize, "osize"},
+	{MatchAddressSize, "asize"},
+	{MatchEscape, "escape"},
+	{MatchOpcode, "opcode"},
+	{MatchModRMReg, "modrm.reg"},
+	{MatchModRMMod, "modrm.mod"},
+	{MatchW, "w"},
+	{MatchL, "l"},
+	{MatchSuffix, "suffix"},
+	{MatchEVEXB, "evex.b"},
+}
+
+// Has returns true if all the fields in f2 are set in f.
+func (f MatchField) Has(f2 MatchField) bool {
+	return f&f2 == f2
+}
+
+// count returns the number of fields set in f.
+func (f MatchField) count() int {
+	n := 0
+	for ; f != 0; f &= f - 1 {
+		n++
+	}
+	return n
+}
+
+// String implements the stringer interface for MatchField. Field names are
+// comma separated.
+func (f MatchField) String() string {
+	var names []string
+	for _, info := range matchFieldTab {
+		if f&info.field != 0 {
+			names = append(names, info.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Match is an instruction form matching a byte sequence.
+type Match struct {
+	Instruction *Instruction
+	// Length is the number of bytes of the instruction, immediates
+	// included.
+	Length int
+	// Fields are the encoding fields constrained by the instruction pattern
+	// and found in the bytes.
+	Fields MatchField
+}
+
+// opcodeKey indexes instructions by their first opcode byte.
+type opcodeKey struct {
+	space  EncodingSpace
+	m      OpcodeMap
+	opcode byte
+}
+
+// buildOpcodeIndex indexes the instructions by opcode for MatchBytes.
+func (idx *index) buildOpcodeIndex(insns InstructionSlice) {
+	idx.byOpcode = make(map[opcodeKey][]*Instruction)
+	idx.waitOnly = nil
+
+	for i := range insns {
+		insn := &insns[i]
+		e := &insn.Encoding
+		if len(e.Opcode) == 0 {
+			if e.Flags.Has(EncodingWait) {
+				idx.waitOnly = append(idx.waitOnly, insn)
+			}
+			continue
+		}
+
+		add := func(opcode byte) {
+			key := opcodeKey{e.Space, e.Map, opcode}
+			idx.byOpcode[key] = append(idx.byOpcode[key], insn)
+		}
+
+		opcode := e.Opcode[0]
+		switch {
+		case e.Add == AddRegister && len(e.Opcode) == 1:
+			for r := byte(0); r < 8; r++ {
+				add(opcode + r)
+			}
+		case e.Add == AddCondition && len(e.Opcode) == 1:
+			for cc := byte(0); cc < 16; cc++ {
+				add(opcode ^ cc)
+			}
+		default:
+			add(opcode)
+		}
+	}
+}
+
+// header holds the fields found before the opcode bytes.
+type header struct {
+	// opsize and adsize are set when 0x66 and 0x67 are present.
+	opsize bool
+	adsize bool
+	// rep is the last of the 0xf2 and 0xf3 prefixes, 0 if none.
+	rep   byte
+	rexW  bool
+	rexR  bool
+	rexB  bool
+	space EncodingSpace
+	m     OpcodeMap
+	// pp is the prefix encoded in VEX, XOP or EVEX, 0 if none.
+	pp     byte
+	w      bool
+	l      int
+	evexB  bool
+	opcode int
+}
+
+var errTruncated = errors.New("truncated instruction")
+
+var ppPrefixes = []byte{0, 0x66, 0xf3, 0xf2}
+
+// parseHeader decodes the prefixes and escape bytes of a 64-bit mode
+// instruction.
+func parseHeader(code []byte) (*header, error) {
+	h := &header{}
+	i := 0
+
+prefixes:
+	for ; i < len(code); i++ {
+		switch code[i] {
+		case 0x66:
+			h.opsize = true
+		case 0x67:
+			h.adsize = true
+		case 0xf2, 0xf3:
+			h.rep = code[i]
+		case 0xf0, 0x2e, 0x36, 0x3e, 0x26, 0x64, 0x65:
+		default:
+			break prefixes
+		}
+	}
+
+	if i < len(code) && code[i]&0xf0 == 0x40 {
+		h.rexW = code[i]&0x08 != 0
+		h.rexR = code[i]&0x04 != 0
+		h.rexB = code[i]&0x01 != 0
+		i++
+	}
+
+	if i >= len(code) {
+		return nil, errTruncated
+	}
+
+	switch b := code[i]; {
+	case b == 0xc5:
+		if i+2 >= len(code) {
+			return nil, errTruncated
+		}
+		h.space = EncodingVEX
+		h.m = Map0F
+		h.l = int(code[i+1]>>2) & 1
+		h.pp = ppPrefixes[code[i+1]&3]
+		i += 2
+	case b == 0xc4 || (b == 0x8f && i+1 < len(code) && code[i+1]&0x1f >= 8):
+		if i+3 >= len(code) {
+			return nil, errTruncated
+		}
+		h.space = EncodingVEX
+		if b == 0x8f {
+			h.space = EncodingXOP
+		}
+		h.m = OpcodeMap(code[i+1] & 0x1f)
+		h.w = code[i+2]&0x80 != 0
+		h.l = int(code[i+2]>>2) & 1
+		h.pp = ppPrefixes[code[i+2]&3]
+		i += 3
+	case b == 0x62:
+		if i+4 >= len(code) {
+			return nil, errTruncated
+		}
+		h.space = EncodingEVEX
+		h.m = OpcodeMap(code[i+1] & 0x3)
+		h.w = code[i+2]&0x80 != 0
+		h.pp = ppPrefixes[code[i+2]&3]
+		h.l = int(code[i+3]>>5) & 3
+		h.evexB = code[i+3]&0x10 != 0
+		i += 4
+	case b == 0x0f:
+		h.m = Map0F
+		i++
+		if i < len(code) && (code[i] == 0x38 || code[i] == 0x3a) {
+			h.m = Map0F38
+			if code[i] == 0x3a {
+				h.m = Map0F3A
+			}
+			i++
+		}
+	}
+
+	if i >= len(code) {
+		return nil, errTruncated
+	}
+	h.opcode = i
+
+	return h, nil
+}
+
+// operandSize returns the operand size of an instruction using e.
+func (h *header) operandSize(e *Encoding) int {
+	switch e.OperandSize {
+	case Size16:
+		return 16
+	case Size32:
+		return 32
+	case Size64, Size64NoW:
+		return 64
+	}
+	if h.rexW {
+		return 64
+	}
+	if h.opsize && e.Prefix != 0x66 {
+		return 16
+	}
+	if e.OperandSize == SizeDefault {
+		return 64
+	}
+	return 32
+}
+
+// modRMLength returns the number of bytes of the ModRM byte, SIB byte and
+// displacement starting at code[0].
+func modRMLength(code []byte) (int, error) {
+	if len(code) == 0 {
+		return 0, errTruncated
+	}
+
+	mod, rm := code[0]>>6, code[0]&7
+	if mod == 3 {
+		return 1, nil
+	}
+
+	n := 1
+	base := rm
+	if rm == 4 {
+		if len(code) < 2 {
+			return 0, errTruncated
+		}
+		base = code[1] & 7
+		n++
+	}
+
+	switch {
+	case mod == 1:
+		n++
+	case mod == 2, base == 5:
+		n += 4
+	}
+
+	return n, nil
+}
+
+// matchPrefixes checks the mandatory prefix and size markers of e.
+func (h *header) matchPrefixes(e *Encoding) (MatchField, bool) {
+	var fields MatchField
+
+	if e.Space == EncodingLegacy {
+		switch {
+		case e.Prefix == 0x66:
+			if !h.opsize {
+				return 0, false
+			}
+			fields |= MatchPrefix
+		case e.Prefix != 0:
+			if h.rep != e.Prefix {
+				return 0, false
+			}
+			fields |= MatchPrefix
+		case e.Flags.Has(EncodingNP):
+			if h.opsize || h.rep != 0 {
+				return 0, false
+			}
+			fields |= MatchPrefix
+		}
+
+		if e.Flags.Has(EncodingNoF3) && h.rep == 0xf3 ||
+			e.Flags.Has(EncodingNoRexB) && h.rexB {
+			return 0, false
+		}
+		if e.Flags.Has(EncodingMustRep) && h.rep != 0xf3 ||
+			e.Flags.Has(EncodingMustRepNE) && h.rep != 0xf2 {
+			return 0, false
+		}
+	} else {
+		if h.pp != e.Prefix {
+			return 0, false
+		}
+		fields |= MatchPrefix
+	}
+
+	opsize := h.opsize && e.Prefix != 0x66
+	switch e.OperandSize {
+	case Size16:
+		if !opsize || h.rexW {
+			return 0, false
+		}
+		fields |= MatchOperandSize
+	case Size32:
+		if opsize || h.rexW {
+			return 0, false
+		}
+		fields |= MatchOperandSize
+	case Size64:
+		if !h.rexW {
+			return 0, false
+		}
+		fields |= MatchOperandSize
+	case Size64NoW:
+		if opsize || h.rexW {
+			return 0, false
+		}
+		fields |= MatchOperandSize
+	}
+
+	switch e.AddressSize {
+	case Size16:
+		return 0, false
+	case Size32:
+		if !h.adsize {
+			return 0, false
+		}
+		fields |= MatchAddressSize
+	case Size64:
+		if h.adsize {
+			return 0, false
+		}
+		fields |= MatchAddressSize
+	}
+
+	return fields, true
+}
+
+// matchVector checks the W and L fields of a VEX, XOP or EVEX encoding.
+func (h *header) matchVector(e *Encoding, modRM byte) (MatchField, bool) {
+	var fields MatchField
+
+	switch e.W {
+	case W0, W1:
+		if h.w != (e.W == W1) {
+			return 0, false
+		}
+		fields |= MatchW
+	}
+
+	// With EVEX.b set, register forms use L'L for the rounding mode.
+	if e.LIG || e.VectorLength == 0 || (h.evexB && modRM>>6 == 3) {
+		return fields, true
+	}
+	if h.l != e.VectorLength/256 {
+		return 0, false
+	}
+	return fields | MatchL, true
+}
+
+// match checks the instruction insn against code, h being its decoded header.
+func (h *header) match(insn *Instruction, code []byte) (Match, bool) {
+	e := &insn.Encoding
+	fields := MatchOpcode
+	if h.m != MapPrimary {
+		fields |= MatchEscape
+	}
+
+	f, ok := h.matchPrefixes(e)
+	if !ok {
+		return Match{}, false
+	}
+	fields |= f
+
+	// Opcode bytes
+	i := h.opcode
+	if i+len(e.Opcode) > len(code) {
+		return Match{}, false
+	}
+	for n, b := range e.Opcode {
+		c := code[i+n]
+		last := n == len(e.Opcode)-1
+		switch {
+		case last && e.Add == AddRegister:
+			c &^= 7
+		case last && e.Add == AddCondition && (c^b)&^0xf == 0:
+			c = b
+		}
+		if c != b {
+			return Match{}, false
+		}
+	}
+	// reg32na and friends can't be the accumulator.
+	if e.Add == AddRegister && e.OpcodeOperand >= 0 &&
+		e.OpcodeOperand < len(insn.Args) &&
+		insn.Args[e.OpcodeOperand].NotAccumulator &&
+		code[i+len(e.Opcode)-1]&7 == 0 && !h.rexB {
+		return Match{}, false
+	}
+	i += len(e.Opcode)
+
+	// ModRM, SIB and displacement
+	suffixMatched := false
+	if e.ModRM != nil {
+		n, err := modRMLength(code[i:])
+		if err != nil {
+			return Match{}, false
+		}
+		modRM := code[i]
+
+		if e.ModRM.Digit >= 0 {
+			if int(modRM>>3&7) != e.ModRM.Digit {
+				return Match{}, false
+			}
+			fields |= MatchModRMReg
+		}
+		if reg := e.ModRM.Reg; reg >= 0 && reg < len(insn.Args) &&
+			!matchFixed(&insn.Args[reg], int(modRM>>3&7), h.rexR) {
+			return Match{}, false
+		}
+
+		if rm := e.ModRM.RM; rm >= 0 && rm < len(insn.Args) {
+			if modRM>>6 == 3 && !matchFixed(&insn.Args[rm], int(modRM&7), h.rexB) {
+				return Match{}, false
+			}
+			switch insn.Args[rm].Kind {
+			case OperandRegister:
+				if modRM>>6 != 3 {
+					return Match{}, false
+				}
+				fields |= MatchModRMMod
+			case OperandMemory:
+				if modRM>>6 == 3 {
+					return Match{}, false
+				}
+				fields |= MatchModRMMod
+			}
+		}
+
+		if e.Space != EncodingLegacy {
+			f, ok := h.matchVector(e, modRM)
+			if !ok {
+				return Match{}, false
+			}
+			fields |= f
+		}
+
+		if h.evexB {
+			if !matchEVEXB(insn, modRM>>6 == 3) {
+				return Match{}, false
+			}
+			fields |= MatchEVEXB
+		}
+
+		i += n
+		if !matchSuffix(e.Suffix, code, &i) {
+			return Match{}, false
+		}
+		suffixMatched = true
+	} else if e.Space != EncodingLegacy {
+		f, ok := h.matchVector(e, 0)
+		if !ok {
+			return Match{}, false
+		}
+		fields |= f
+	}
+
+	// Immediates. Without a ModRM byte, the literal bytes follow the first
+	// immediate, eg. "jlen e9" in the "jcc over jmp" form.
+	operandSize, addressSize := h.operandSize(e), 64
+	if h.adsize {
+		addressSize = 32
+	}
+	for n, imm := range e.Immediates {
+		i += imm.Type.Length(operandSize, addressSize)
+		if n == 0 && !suffixMatched {
+			if !matchSuffix(e.Suffix, code, &i) {
+				return Match{}, false
+			}
+			suffixMatched = true
+		}
+	}
+	if !suffixMatched && !matchSuffix(e.Suffix, code, &i) {
+		return Match{}, false
+	}
+	if len(e.Suffix) > 0 {
+		fields |= MatchSuffix
+	}
+
+	if i > len(code) {
+		return Match{}, false
+	}
+
+	return Match{insn, i, fields}, true
+}
+
+// fixedRegisterNumbers are the register numbers of the fixed registers.
+var fixedRegisterNumbers = map[string]int{
+	"al": 0, "ax": 0, "eax": 0, "rax": 0,
+	"cl": 1, "cx": 1, "ecx": 1, "rcx": 1,
+	"dx": 2, "edx": 2,
+	"es": 0, "cs": 1, "ss": 2, "ds": 3, "fs": 4, "gs": 5,
+	"st0": 0, "xmm0": 0,
+}
+
+// matchFixed checks a register operand encoded in a ModRM field is the fixed
+// register of arg, if any. num is the field value and rex the REX extension
+// bit.
+func matchFixed(arg *Operand, num int, rex bool) bool {
+	if arg.Fixed == "" {
+		return true
+	}
+	if rex {
+		num |= 8
+	}
+	n, ok := fixedRegisterNumbers[arg.Fixed]
+	return ok && n == num
+}
+
+// matchEVEXB checks insn has an operand allowing EVEX.b to be set: rounding
+// or SAE for register forms, broadcast for memory forms.
+func matchEVEXB(insn *Instruction, register bool) bool {
+	for _, arg := range insn.Args {
+		if register && (arg.Rounding || arg.SAE) {
+			return true
+		}
+		if !register && arg.Broadcast != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSuffix checks the literal bytes suffix are found at code[*i].
+func matchSuffix(suffix []byte, code []byte, i *int) bool {
+	if *i+len(suffix) > len(code) {
+		return false
+	}
+	for n, b := range suffix {
+		if code[*i+n] != b {
+			return false
+		}
+	}
+	*i += len(suffix)
+	return true
+}
+
+func (db *DB) matchBytes(code []byte, wait bool) ([]Match, error) {
+	h, err := parseHeader(code)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	key := opcodeKey{h.space, h.m, code[h.opcode]}
+	for _, insn := range db.index.byOpcode[key] {
+		if insn.Encoding.Flags.Has(EncodingWait) != wait ||
+			!insn.Modes.Has(Mode64) {
+			continue
+		}
+		if m, ok := h.match(insn, code); ok {
+			matches = append(matches, m)
+		}
+	}
+
+	return matches, nil
+}
+
+// MatchBytes returns the instruction forms whose encoding matches the start of
+// code, decoded as 64-bit mode code. Matches are sorted from the most to the
+// least specific form, ie. by decreasing number of matched fields, the forms
+// flagged ND coming last.
+func (db *DB) MatchBytes(code []byte) ([]Match, error) {
+	if len(code) == 0 {
+		return nil, errTruncated
+	}
+
+	matches, err := db.matchBytes(code, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Instructions with a "wait" code are preceded by WAIT (9B), FWAIT
+	// being the WAIT byte alone.
+	if code[0] == 0x9b {
+		for _, insn := range db.index.waitOnly {
+			if insn.Modes.Has(Mode64) {
+				matches = append(matches, Match{insn, 1, MatchWait})
+			}
+		}
+	}
+	if code[0] == 0x9b && len(code) > 1 {
+		waits, err := db.matchBytes(code[1:], true)
+		if err == nil {
+			for _, m := range waits {
+				m.Length++
+				m.Fields |= MatchWait
+				matches = append(matches, m)
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		ndi := matches[i].Instruction.Attr.Has(AttrND)
+		ndj := matches[j].Instruction.Attr.Has(AttrND)
+		if ndi != ndj {
+			return ndj
+		}
+		return matches[i].Fields.count() > matches[j].Fields.count()
+	})
+
+	return matches, nil
+}
@@ -0,0 +1,409 @@
+package x86db
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OperandSpec describes an actual operand to match against the operands of
+// instruction forms, see DB.MatchOperands.
+type OperandSpec struct {
+	Operand
+	// Register is the number of a register given by name, eg. 10 for r10d.
+	Register int
+	// Value is the value of an immediate operand, when HasValue is set.
+	Value    int64
+	HasValue bool
+}
+
+// gpRegisters lists the general purpose registers by size, in register
+// number order.
+var gpRegisters = []struct {
+	operand string
+	names   [16]string
+}{
+	{"reg8", [16]string{"al", "cl", "dl", "bl", "spl", "bpl", "sil", "dil",
+		"r8b", "r9b", "r10b", "r11b", "r12b", "r13b", "r14b", "r15b"}},
+	{"reg16", [16]string{"ax", "cx", "dx", "bx", "sp", "bp", "si", "di",
+		"r8w", "r9w", "r10w", "r11w", "r12w", "r13w", "r14w", "r15w"}},
+	{"reg32", [16]string{"eax", "ecx", "edx", "ebx", "esp", "ebp", "esi", "edi",
+		"r8d", "r9d", "r10d", "r11d", "r12d", "r13d", "r14d", "r15d"}},
+	{"reg64", [16]string{"rax", "rcx", "rdx", "rbx", "rsp", "rbp", "rsi", "rdi",
+		"r8", "r9", "r10", "r11", "r12", "r13", "r14", "r15"}},
+}
+
+// highByteRegisters can't be encoded with a REX prefix.
+var highByteRegisters = []string{"ah", "ch", "dh", "bh"}
+
+// rexByteRegisters need a REX prefix.
+var rexByteRegisters = map[string]bool{
+	"spl": true, "bpl": true, "sil": true, "dil": true,
+}
+
+var segmentRegisters = []string{"es", "cs", "ss", "ds", "fs", "gs"}
+
+// registerFamilies are the prefixes of numbered register names, eg. xmm12,
+// and the nasm operand describing them.
+var registerFamilies = []struct {
+	prefix  string
+	count   int
+	operand string
+}{
+	{"xmm", 32, "xmmreg"},
+	{"ymm", 32, "ymmreg"},
+	{"zmm", 32, "zmmreg"},
+	{"mm", 8, "mmxreg"},
+	{"st", 8, "fpureg"},
+	{"k", 8, "kreg"},
+	{"bnd", 4, "bndreg"},
+	{"cr", 16, "reg_creg"},
+	{"dr", 16, "reg_dreg"},
+}
+
+// fixedOperand returns the nasm operand only accepting the register name, if
+// any, eg. "reg_eax" for eax.
+func fixedOperand(name string) (string, bool) {
+	for operand, fixed := range fixedRegisters {
+		if fixed == name {
+			return operand, true
+		}
+	}
+	return "", false
+}
+
+// registerOperand returns the nasm operand describing the register name and
+// the register number.
+func registerOperand(name string) (string, int, bool) {
+	if operand, ok := fixedOperand(name); ok {
+		for n, segment := range segmentRegisters {
+			if segment == name {
+				return operand, n, true
+			}
+		}
+	}
+
+	for _, gp := range gpRegisters {
+		for n, gpName := range gp.names {
+			if gpName != name {
+				continue
+			}
+			if operand, ok := fixedOperand(name); ok {
+				return operand, n, true
+			}
+			return gp.operand, n, true
+		}
+	}
+	for n, highByte := range highByteRegisters {
+		if highByte == name {
+			return "reg8", n + 4, true
+		}
+	}
+
+	for _, f := range registerFamilies {
+		if !strings.HasPrefix(name, f.prefix) {
+			continue
+		}
+		n, err := strconv.Atoi(name[len(f.prefix):])
+		if err != nil || n < 0 || n >= f.count {
+			continue
+		}
+		if operand, ok := fixedOperand(name); ok {
+			return operand, n, true
+		}
+		return f.operand, n, true
+	}
+
+	return "", 0, false
+}
+
+// OperandSpecFromString parses an operand spec. It can be a nasm operand, eg.
+// "xmmrm128" or "mem512|b32", a register name, eg. "eax" or "zmm3", or an
+// integer immediate, eg. "1" or "-0x80".
+func OperandSpecFromString(str string) (OperandSpec, error) {
+	if v, err := strconv.ParseInt(str, 0, 64); err == nil {
+		return OperandSpec{
+			Operand:  Operand{Raw: str, Kind: OperandImmediate},
+			Value:    v,
+			HasValue: true,
+		}, nil
+	}
+
+	base, decorators := str, ""
+	if sep := strings.IndexByte(str, '|'); sep >= 0 {
+		base, decorators = str[:sep], str[sep:]
+	}
+	operand, register, ok := registerOperand(strings.ToLower(base))
+	if ok {
+		base = operand
+	}
+
+	op, err := OperandFromString(base + decorators)
+	if err != nil {
+		return OperandSpec{}, fmt.Errorf("invalid operand spec '%s': %v", str, err)
+	}
+	op.Raw = str
+
+	return OperandSpec{Operand: op, Register: register}, nil
+}
+
+// accumulators are the registers reg32na and friends don't accept.
+var accumulators = map[string]bool{
+	"al": true, "ax": true, "eax": true, "rax": true,
+}
+
+// fits returns true if v can be represented in bits, sign or zero extended.
+func fits(v int64, bits int) bool {
+	if bits >= 64 {
+		return true
+	}
+	return v >= -(1<<uint(bits-1)) && v < 1<<uint(bits)
+}
+
+// immediateSize returns the number of bits needed by an immediate spec, 0 if
+// unknown.
+func (s *OperandSpec) immediateSize() int {
+	switch s.Range {
+	case ImmediateUnity, ImmediateSByte:
+		return 8
+	case ImmediateSDword, ImmediateUDword:
+		return 32
+	}
+	return s.Size
+}
+
+// acceptsImmediate returns true if the immediate form accepts spec.
+func (form *Operand) acceptsImmediate(spec *OperandSpec) bool {
+	if spec.Kind != OperandImmediate && spec.Kind != OperandRelative {
+		return false
+	}
+
+	size := spec.immediateSize()
+	switch form.Range {
+	case ImmediateUnity:
+		return spec.HasValue && spec.Value == 1 || spec.Range == ImmediateUnity
+	case ImmediateSByte:
+		if spec.HasValue {
+			return spec.Value >= -128 && spec.Value < 128
+		}
+		return size == 8
+	case ImmediateSDword:
+		if spec.HasValue {
+			return spec.Value >= -1<<31 && spec.Value < 1<<31
+		}
+		return size != 0 && size <= 32 && spec.Range != ImmediateUDword
+	case ImmediateUDword:
+		if spec.HasValue {
+			return spec.Value >= 0 && spec.Value < 1<<32
+		}
+		return size != 0 && size <= 32
+	}
+
+	if form.Size == 0 {
+		return true
+	}
+	if spec.HasValue {
+		return fits(spec.Value, form.Size)
+	}
+	return size <= form.Size
+}
+
+// acceptsRegister returns true if the register part of a register or reg-or-mem
+// form accepts the register spec.
+func (form *Operand) acceptsRegister(spec *OperandSpec) bool {
+	if spec.Kind != OperandRegister || spec.Class != form.Class {
+		return false
+	}
+
+	if form.Fixed != "" {
+		return spec.Fixed == form.Fixed
+	}
+	if form.NotAccumulator && accumulators[spec.Fixed] {
+		return false
+	}
+
+	// The size of reg-or-mem forms is the memory size, only general purpose
+	// registers have to match it.
+	if form.Kind == OperandRegMem && form.Class != RegisterClassGP {
+		return true
+	}
+	return form.Size == 0 || spec.Size == 0 || form.Size == spec.Size
+}
+
+// acceptsMemory returns true if the memory part of a memory or reg-or-mem form
+// accepts the memory spec.
+func (form *Operand) acceptsMemory(spec *OperandSpec) bool {
+	if spec.Kind != OperandMemory || spec.VSIB != form.VSIB {
+		return false
+	}
+	if spec.Broadcast != 0 {
+		return form.Broadcast == spec.Broadcast
+	}
+	return form.Size == 0 || spec.Size == 0 || form.Size == spec.Size
+}
+
+// accepts returns true if the operand form accepts spec.
+func (form *Operand) accepts(spec *OperandSpec) bool {
+	if spec.Mask && !form.Mask || spec.Zeroing && !form.Zeroing ||
+		spec.Rounding && !form.Rounding ||
+		spec.SAE && !form.SAE && !form.Rounding {
+		return false
+	}
+	// Forms with a modifier need it to be spelled out, far is never
+	// implied.
+	if form.Modifier != ModifierNone && spec.Modifier != form.Modifier ||
+		spec.Modifier == ModifierFar && form.Modifier != ModifierFar {
+		return false
+	}
+
+	switch form.Kind {
+	case OperandImmediate, OperandRelative:
+		return form.acceptsImmediate(spec)
+	case OperandRegister:
+		return form.acceptsRegister(spec)
+	case OperandMemory:
+		return form.acceptsMemory(spec)
+	case OperandRegMem:
+		if spec.Kind == OperandRegMem {
+			return spec.Class == form.Class &&
+				(form.Size == 0 || spec.Size == form.Size)
+		}
+		return form.acceptsRegister(spec) || form.acceptsMemory(spec)
+	}
+
+	return false
+}
+
+// specificity ranks operand forms, higher values accepting fewer operands.
+func (form *Operand) specificity() int {
+	n := 0
+	switch form.Kind {
+	case OperandRegister, OperandMemory:
+		n = 2
+	case OperandRegMem:
+		n = 1
+	case OperandImmediate, OperandRelative:
+		n = 1
+		if form.Range == ImmediateUnity {
+			n += 3
+		} else if form.Range != ImmediateAny {
+			n += 2
+		}
+	}
+	if form.Fixed != "" {
+		n += 2
+	}
+	if form.Size != 0 {
+		n++
+	}
+	return n
+}
+
+// alignOperands returns specs lined up with the operands of the instruction
+// form. An omitted '*' operand is a copy of the first operand.
+func (insn *Instruction) alignOperands(specs []OperandSpec) ([]OperandSpec, bool) {
+	args := insn.Args
+	if len(specs) == len(args) {
+		return specs, true
+	}
+	if len(specs) != len(args)-1 || len(specs) == 0 {
+		return nil, false
+	}
+
+	for i := range args {
+		if args[i].OptionalNDS {
+			aligned := make([]OperandSpec, 0, len(args))
+			aligned = append(aligned, specs[:i]...)
+			aligned = append(aligned, specs[0])
+			return append(aligned, specs[i:]...), true
+		}
+	}
+	return nil, false
+}
+
+// Accepts returns true if the instruction form accepts the operands. An
+// operand marked with '*' can be omitted.
+func (insn *Instruction) Accepts(specs ...OperandSpec) bool {
+	aligned, ok := insn.alignOperands(specs)
+	if !ok {
+		return false
+	}
+
+	if !insn.encodesHighByte(aligned) {
+		return false
+	}
+	for i := range insn.Args {
+		// Registers 16 to 31 can only be encoded with EVEX.
+		if aligned[i].Kind == OperandRegister && aligned[i].Register >= 16 &&
+			insn.Encoding.Space != EncodingEVEX {
+			return false
+		}
+		if !insn.Args[i].accepts(&aligned[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// encodesHighByte returns false when ah, ch, dh or bh are used along with an
+// operand or an encoding needing a REX, VEX, XOP or EVEX prefix.
+func (insn *Instruction) encodesHighByte(specs []OperandSpec) bool {
+	highByte, rex := false, false
+	for i := range specs {
+		name := strings.ToLower(specs[i].Raw)
+		for _, r := range highByteRegisters {
+			if name == r {
+				highByte = true
+			}
+		}
+		if specs[i].Kind == OperandRegister && specs[i].Register >= 8 ||
+			rexByteRegisters[name] {
+			rex = true
+		}
+	}
+	if !highByte {
+		return true
+	}
+
+	e := &insn.Encoding
+	if e.Space != EncodingLegacy ||
+		e.OperandSize == Size64 && !e.Flags.Has(EncodingNoRexW) {
+		return false
+	}
+	return !rex
+}
+
+func (insn *Instruction) specificity() int {
+	n := 0
+	for i := range insn.Args {
+		n += insn.Args[i].specificity()
+	}
+	return n
+}
+
+// MatchOperands returns the forms of mnemonic accepting the given operands,
+// ordered from the most to the least specific. An empty mnemonic matches all
+// instructions. The comparison of mnemonics is case insensitive.
+//
+// Matching follows the nasm operand subtyping: xmmrm128 accepts both xmmreg
+// and mem128, sbytedword accepts an imm8, reg32 accepts reg_eax, ...
+func (db *DB) MatchOperands(mnemonic string, specs ...OperandSpec) InstructionSlice {
+	insns := db.Instructions
+	if mnemonic != "" {
+		insns = db.LookupFold(mnemonic)
+	}
+
+	var result InstructionSlice
+	for i := range insns {
+		if insns[i].Accepts(specs...) {
+			result = append(result, insns[i])
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].specificity() > result[j].specificity()
+	})
+
+	return result
+}
@@ -0,0 +1,99 @@
+package x86db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperandSpecFromString(t *testing.T) {
+	tests := []struct {
+		str   string
+		valid bool
+		kind  OperandKind
+		class RegisterClass
+		size  int
+		fixed string
+	}{
+		{"xmmrm128", true, OperandRegMem, RegisterClassXMM, 128, ""},
+		{"eax", true, OperandRegister, RegisterClassGP, 32, "eax"},
+		{"EBX", true, OperandRegister, RegisterClassGP, 32, ""},
+		{"r10d", true, OperandRegister, RegisterClassGP, 32, ""},
+		{"zmm31", true, OperandRegister, RegisterClassZMM, 512, ""},
+		{"k1", true, OperandRegister, RegisterClassMask, 64, ""},
+		{"-0x80", true, OperandImmediate, RegisterClassNone, 0, ""},
+		{"zmm32", false, 0, 0, 0, ""},
+		{"foo", false, 0, 0, 0, ""},
+	}
+
+	for _, test := range tests {
+		spec, err := OperandSpecFromString(test.str)
+		if !test.valid {
+			assert.NotNil(t, err, test.str)
+			continue
+		}
+		assert.Nil(t, err, test.str)
+		assert.Equal(t, test.kind, spec.Kind, test.str)
+		assert.Equal(t, test.class, spec.Class, test.str)
+		assert.Equal(t, test.size, spec.Size, test.str)
+		assert.Equal(t, test.fixed, spec.Fixed, test.str)
+	}
+}
+
+func TestMatchOperands(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+
+	tests := []struct {
+		mnemonic string
+		specs    []string
+		// forms lists the operands of the matched forms, in order.
+		forms []string
+	}{
+		{"ADD", []string{"eax", "1"}, []string{
+			"reg_eax,sbytedword", "reg_eax,imm", "rm32,sbytedword",
+			"rm32,imm8", "rm32,imm",
+		}},
+		{"add", []string{"ecx", "imm8"}, []string{
+			"rm32,sbytedword", "rm32,imm8", "rm32,imm",
+		}},
+		{"ADD", []string{"rax", "0x1000"}, []string{
+			"reg_rax,imm", "rm64,imm",
+		}},
+		{"VPADDD", []string{"zmm1", "zmm2", "mem512|b32"}, []string{
+			"zmmreg|mask|z,zmmreg*,zmmrm512|b32",
+		}},
+		{"VADDPS", []string{"xmm1", "xmm2"}, []string{
+			"xmmreg,xmmreg*,xmmrm128", "xmmreg|mask|z,xmmreg*,xmmrm128|b32",
+		}},
+		{"SHL", []string{"eax", "1"}, []string{"rm32,unity", "rm32,imm8"}},
+		{"PINSRD", []string{"xmm1", "mem32", "3"}, []string{
+			"xmmreg,mem,imm", "xmmreg,rm32,imm",
+		}},
+		{"PINSRD", []string{"xmm1", "rax", "3"}, nil},
+		{"ADD", []string{"mem", "eax"}, []string{"mem,reg32"}},
+		{"ADD", []string{"mem", "ymm0"}, nil},
+		// ah, ch, dh and bh can't be encoded with a REX prefix.
+		{"ADD", []string{"ah", "r8b"}, nil},
+		{"MOV", []string{"ah", "sil"}, nil},
+		{"MOVZX", []string{"rax", "bh"}, nil},
+		{"VPEXTRB", []string{"ah", "xmm0", "1"}, nil},
+		{"ADD", []string{"ah", "cl"}, []string{"reg8,reg8", "reg8,reg8"}},
+	}
+
+	for _, test := range tests {
+		var specs []OperandSpec
+		for _, str := range test.specs {
+			spec, err := OperandSpecFromString(str)
+			assert.Nil(t, err)
+			specs = append(specs, spec)
+		}
+
+		var forms []string
+		for _, insn := range db.MatchOperands(test.mnemonic, specs...) {
+			forms = append(forms, strings.Join(insn.Operands, ","))
+		}
+		assert.Equal(t, test.forms, forms, "%s %v", test.mnemonic, test.specs)
+	}
+}
@@ -0,0 +1,144 @@
+package x86db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchBytes(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+
+	tests := []struct {
+		code     []byte
+		valid    bool
+		name     string
+		operands string
+		length   int
+		fields   MatchField
+	}{
+		{[]byte{0x66, 0x0f, 0xd0, 0xc1}, true, "ADDSUBPD", "xmmreg,xmmrm", 4,
+			MatchPrefix | MatchEscape | MatchOpcode},
+		{[]byte{0x62, 0xf1, 0x7c, 0x48, 0x58, 0xc1}, true,
+			"VADDPS", "zmmreg|mask|z,zmmreg*,zmmrm512|b32|er", 6,
+			MatchPrefix | MatchEscape | MatchOpcode | MatchW | MatchL},
+		{[]byte{0x62, 0xf1, 0x7c, 0x18, 0x58, 0xc1}, true,
+			"VADDPS", "zmmreg|mask|z,zmmreg*,zmmrm512|b32|er", 6,
+			MatchPrefix | MatchEscape | MatchOpcode | MatchW | MatchEVEXB},
+		{[]byte{0xc5, 0xf8, 0x58, 0xc1}, true,
+			"VADDPS", "xmmreg,xmmreg*,xmmrm128", 4,
+			MatchPrefix | MatchEscape | MatchOpcode | MatchL},
+		{[]byte{0xf3, 0x0f, 0xbc, 0xc1}, true, "TZCNT", "reg32,rm32", 4,
+			MatchPrefix | MatchOperandSize | MatchEscape | MatchOpcode},
+		{[]byte{0x48, 0x83, 0xc0, 0x01}, true, "ADD", "reg_rax,sbytedword", 4,
+			MatchOperandSize | MatchOpcode | MatchModRMReg | MatchModRMMod},
+		{[]byte{0x48, 0x81, 0x44, 0x24, 0x08, 1, 2, 3, 4}, true,
+			"ADD", "rm64,imm", 9, MatchOperandSize | MatchOpcode | MatchModRMReg},
+		{[]byte{0x9b, 0xdf, 0xe0}, true, "FSTSW", "reg_ax", 3,
+			MatchWait | MatchOpcode},
+		{[]byte{0x9b}, true, "FWAIT", "void", 1, MatchWait},
+		{[]byte{0x9b, 0x90}, true, "FWAIT", "void", 1, MatchWait},
+		{[]byte{0x0f, 0xc2, 0xc1, 0x00}, true, "CMPEQPS", "xmmreg,xmmrm128", 4,
+			MatchPrefix | MatchEscape | MatchOpcode | MatchSuffix},
+		{[]byte{0x8f, 0xe8, 0x78, 0xcc, 0xc1, 0x00}, true,
+			"VPCOMB", "xmmreg,xmmreg*,xmmrm128,imm8", 6,
+			MatchPrefix | MatchEscape | MatchOpcode | MatchW | MatchL},
+		{[]byte{0xf3, 0x90}, true, "PAUSE", "void", 2,
+			MatchPrefix | MatchOpcode},
+		{[]byte{0x48, 0xb8, 1, 2, 3, 4, 5, 6, 7, 8}, true, "MOV", "reg64,imm", 10,
+			MatchOperandSize | MatchOpcode},
+		{[]byte{0x48, 0x83, 0xc1, 0x01}, true, "ADD", "rm64,imm8", 4,
+			MatchOperandSize | MatchOpcode | MatchModRMReg},
+		{[]byte{0x83, 0xc1, 0x01}, true, "ADD", "rm32,imm8", 3,
+			MatchOperandSize | MatchOpcode | MatchModRMReg},
+		{[]byte{}, false, "", "", 0, 0},
+		{[]byte{0x66, 0x0f}, false, "", "", 0, 0},
+	}
+
+	for _, test := range tests {
+		matches, err := db.MatchBytes(test.code)
+		if !test.valid {
+			assert.NotNil(t, err)
+			continue
+		}
+
+		assert.Nil(t, err)
+		found := false
+		for _, m := range matches {
+			if m.Instruction.Name == test.name &&
+				strings.Join(m.Instruction.Operands, ",") == test.operands {
+				found = true
+				assert.Equal(t, test.length, m.Length, test.name)
+				assert.Equal(t, test.fields, m.Fields, test.name)
+			}
+		}
+		assert.True(t, found, test.name)
+	}
+
+	// Rebuilding the opcode index doesn't duplicate the WAIT forms.
+	db.index.buildOpcodeIndex(db.Instructions)
+	matches, err := db.MatchBytes([]byte{0x9b})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(matches))
+
+	// The ModRM fields must hold the fixed registers: add rcx,1 isn't
+	// ADD reg_rax,sbytedword.
+	for _, code := range [][]byte{{0x48, 0x83, 0xc1, 0x01}, {0x83, 0xc1, 0x01}} {
+		matches, err := db.MatchBytes(code)
+		assert.Nil(t, err)
+		for _, m := range matches {
+			for _, arg := range m.Instruction.Args {
+				assert.Equal(t, "", arg.Fixed, m.Instruction.Operands)
+			}
+		}
+	}
+}
+
+func TestMatchBytesOrder(t *testing.T) {
+	db := NewDB()
+	assert.Nil(t, db.Open())
+
+	// PAUSE is more specific than XCHG eax,eax
+	matches, err := db.MatchBytes([]byte{0xf3, 0x90})
+	assert.Nil(t, err)
+	if assert.NotEmpty(t, matches) {
+		assert.Equal(t, "PAUSE", matches[0].Instruction.Name)
+	}
+
+	tests := []struct {
+		code []byte
+		// forms are the name and operands of the matching forms, in order.
+		forms []string
+	}{
+		// o64nw forms don't accept REX.W.
+		{[]byte{0x48, 0xb8, 1, 2, 3, 4, 5, 6, 7, 8}, []string{"MOV reg64,imm"}},
+		// reg32na can't be eax.
+		{[]byte{0x90}, []string{"NOP void"}},
+		{[]byte{0xf3, 0x90}, []string{"PAUSE void"}},
+		// NOP doesn't accept REX.B.
+		{[]byte{0x41, 0x90}, []string{"XCHG reg_eax,reg32na", "XCHG reg32na,reg_eax"}},
+		// ND forms come last.
+		{[]byte{0x6a, 0x01}, []string{"PUSH imm8", "PUSH sbytedword64",
+			"PUSH sbytedword32"}},
+	}
+
+	for _, test := range tests {
+		matches, err := db.MatchBytes(test.code)
+		assert.Nil(t, err)
+		var forms []string
+		for _, m := range matches {
+			forms = append(forms, m.Instruction.Name+" "+
+				strings.Join(m.Instruction.Operands, ","))
+		}
+		assert.Equal(t, test.forms, forms, "% x", test.code)
+	}
+
+	// BSF doesn't accept a f3 prefix
+	matches, err = db.MatchBytes([]byte{0xf3, 0x0f, 0xbc, 0xc1})
+	assert.Nil(t, err)
+	for _, m := range matches {
+		assert.NotEqual(t, "BSF", m.Instruction.Name)
+	}
+}
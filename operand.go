@@ -0,0 +1,421 @@
+package x86db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OperandKind is the broad category of an instruction operand.
+type OperandKind int
+
+const (
+	OperandNone OperandKind = iota
+	OperandRegister
+	OperandMemory
+	OperandRegMem
+	OperandImmediate
+	OperandRelative
+)
+
+var operandKindNames = []string{
+	OperandNone:      "none",
+	OperandRegister:  "reg",
+	OperandMemory:    "mem",
+	OperandRegMem:    "rm",
+	OperandImmediate: "imm",
+	OperandRelative:  "rel",
+}
+
+// String implements the stringer interface for OperandKind.
+func (k OperandKind) String() string {
+	if k < 0 || int(k) >= len(operandKindNames) {
+		return fmt.Sprintf("OperandKind(%d)", int(k))
+	}
+	return operandKindNames[k]
+}
+
+// RegisterClass is the register file a register (or the register half of a
+// reg-or-mem) operand is taken from.
+type RegisterClass int
+
+const (
+	RegisterClassNone RegisterClass = iota
+	RegisterClassGP
+	RegisterClassSegment
+	RegisterClassControl
+	RegisterClassDebug
+	RegisterClassTest
+	RegisterClassFPU
+	RegisterClassMMX
+	RegisterClassXMM
+	RegisterClassYMM
+	RegisterClassZMM
+	RegisterClassMask
+	RegisterClassBound
+)
+
+var registerClassNames = []string{
+	RegisterClassNone:    "none",
+	RegisterClassGP:      "gp",
+	RegisterClassSegment: "sreg",
+	RegisterClassControl: "creg",
+	RegisterClassDebug:   "dreg",
+	RegisterClassTest:    "treg",
+	RegisterClassFPU:     "fpu",
+	RegisterClassMMX:     "mmx",
+	RegisterClassXMM:     "xmm",
+	RegisterClassYMM:     "ymm",
+	RegisterClassZMM:     "zmm",
+	RegisterClassMask:    "k",
+	RegisterClassBound:   "bnd",
+}
+
+// String implements the stringer interface for RegisterClass.
+func (c RegisterClass) String() string {
+	if c < 0 || int(c) >= len(registerClassNames) {
+		return fmt.Sprintf("RegisterClass(%d)", int(c))
+	}
+	return registerClassNames[c]
+}
+
+// OperandModifier is the near/far/short/to keyword attached to an operand.
+type OperandModifier int
+
+const (
+	ModifierNone OperandModifier = iota
+	ModifierNear
+	ModifierFar
+	ModifierShort
+	ModifierTo
+)
+
+// ImmediateRange restricts the values an immediate operand accepts.
+type ImmediateRange int
+
+const (
+	// ImmediateAny accepts any value that fits the operand size.
+	ImmediateAny ImmediateRange = iota
+	// ImmediateUnity only accepts the value 1.
+	ImmediateUnity
+	// ImmediateSByte accepts values that fit in a sign-extended byte.
+	ImmediateSByte
+	// ImmediateSDword accepts values that fit in a sign-extended dword.
+	ImmediateSDword
+	// ImmediateUDword accepts values that fit in a zero-extended dword.
+	ImmediateUDword
+)
+
+// Operand is the parsed form of a nasm operand such as "zmmreg|mask|z" or
+// "xmmrm128|b32".
+type Operand struct {
+	// Raw is the operand as written in insns.dat.
+	Raw  string
+	Kind OperandKind
+	// Class is the register file of register and reg-or-mem operands.
+	Class RegisterClass
+	// Size is the size of the operand in bits, 0 when unsized. For
+	// reg-or-mem operands, it's the size of the memory access.
+	Size int
+	// Fixed names the only register accepted by the operand, eg. "eax"
+	// for reg_eax or "xmm0" for xmm0.
+	Fixed string
+	// NotAccumulator is set when the accumulator register isn't accepted.
+	NotAccumulator bool
+	// VSIB is the register file of the vector index for VSIB memory
+	// operands, RegisterClassNone otherwise.
+	VSIB RegisterClass
+	// Offset is set for the moffs memory operand of MOV.
+	Offset bool
+	// Range restricts the value of immediate operands.
+	Range ImmediateRange
+	// Mask is set when an opmask register may be applied: {k1}.
+	Mask bool
+	// Zeroing is set when zeroing-masking is allowed: {z}.
+	Zeroing bool
+	// Broadcast is the size in bits of the broadcasted element, 0 when
+	// embedded broadcast isn't allowed.
+	Broadcast int
+	// Rounding is set when embedded rounding control is allowed: {rn-sae}.
+	Rounding bool
+	// SAE is set when suppress-all-exceptions is allowed: {sae}.
+	SAE bool
+	// OptionalNDS marks operands that can be omitted, nasm then duplicates
+	// the first operand (the '*' suffix).
+	OptionalNDS bool
+	// Colon is set for the segment half of a far "seg:offset" pointer.
+	Colon    bool
+	Modifier OperandModifier
+}
+
+type operandInfo struct {
+	name  string
+	kind  OperandKind
+	class RegisterClass
+	size  int
+}
+
+var operandTab = []operandInfo{
+	{"imm", OperandImmediate, RegisterClassNone, 0},
+	{"imm8", OperandImmediate, RegisterClassNone, 8},
+	{"imm16", OperandImmediate, RegisterClassNone, 16},
+	{"imm32", OperandImmediate, RegisterClassNone, 32},
+	{"imm64", OperandImmediate, RegisterClassNone, 64},
+	{"unity", OperandImmediate, RegisterClassNone, 0},
+	{"sbyteword", OperandImmediate, RegisterClassNone, 0},
+	{"sbyteword16", OperandImmediate, RegisterClassNone, 16},
+	{"sbytedword", OperandImmediate, RegisterClassNone, 0},
+	{"sbytedword32", OperandImmediate, RegisterClassNone, 32},
+	{"sbytedword64", OperandImmediate, RegisterClassNone, 64},
+	{"sdword", OperandImmediate, RegisterClassNone, 0},
+	{"udword", OperandImmediate, RegisterClassNone, 0},
+
+	{"mem", OperandMemory, RegisterClassNone, 0},
+	{"mem8", OperandMemory, RegisterClassNone, 8},
+	{"mem16", OperandMemory, RegisterClassNone, 16},
+	{"mem32", OperandMemory, RegisterClassNone, 32},
+	{"mem64", OperandMemory, RegisterClassNone, 64},
+	{"mem80", OperandMemory, RegisterClassNone, 80},
+	{"mem128", OperandMemory, RegisterClassNone, 128},
+	{"mem256", OperandMemory, RegisterClassNone, 256},
+	{"mem512", OperandMemory, RegisterClassNone, 512},
+	{"mem_offs", OperandMemory, RegisterClassNone, 0},
+	{"xmem32", OperandMemory, RegisterClassNone, 32},
+	{"xmem64", OperandMemory, RegisterClassNone, 64},
+	{"ymem32", OperandMemory, RegisterClassNone, 32},
+	{"ymem64", OperandMemory, RegisterClassNone, 64},
+	{"zmem32", OperandMemory, RegisterClassNone, 32},
+	{"zmem64", OperandMemory, RegisterClassNone, 64},
+
+	{"reg", OperandRegister, RegisterClassGP, 0},
+	{"reg8", OperandRegister, RegisterClassGP, 8},
+	{"reg16", OperandRegister, RegisterClassGP, 16},
+	{"reg32", OperandRegister, RegisterClassGP, 32},
+	{"reg32na", OperandRegister, RegisterClassGP, 32},
+	{"reg64", OperandRegister, RegisterClassGP, 64},
+	{"reg_al", OperandRegister, RegisterClassGP, 8},
+	{"reg_ax", OperandRegister, RegisterClassGP, 16},
+	{"reg_eax", OperandRegister, RegisterClassGP, 32},
+	{"reg_rax", OperandRegister, RegisterClassGP, 64},
+	{"reg_cl", OperandRegister, RegisterClassGP, 8},
+	{"reg_cx", OperandRegister, RegisterClassGP, 16},
+	{"reg_ecx", OperandRegister, RegisterClassGP, 32},
+	{"reg_rcx", OperandRegister, RegisterClassGP, 64},
+	{"reg_dx", OperandRegister, RegisterClassGP, 16},
+	{"reg_edx", OperandRegister, RegisterClassGP, 32},
+	{"reg_sreg", OperandRegister, RegisterClassSegment, 16},
+	{"reg_es", OperandRegister, RegisterClassSegment, 16},
+	{"reg_cs", OperandRegister, RegisterClassSegment, 16},
+	{"reg_ss", OperandRegister, RegisterClassSegment, 16},
+	{"reg_ds", OperandRegister, RegisterClassSegment, 16},
+	{"reg_fs", OperandRegister, RegisterClassSegment, 16},
+	{"reg_gs", OperandRegister, RegisterClassSegment, 16},
+	{"reg_creg", OperandRegister, RegisterClassControl, 0},
+	{"reg_dreg", OperandRegister, RegisterClassDebug, 0},
+	{"reg_treg", OperandRegister, RegisterClassTest, 32},
+	{"rm8", OperandRegMem, RegisterClassGP, 8},
+	{"rm16", OperandRegMem, RegisterClassGP, 16},
+	{"rm32", OperandRegMem, RegisterClassGP, 32},
+	{"rm64", OperandRegMem, RegisterClassGP, 64},
+
+	{"fpureg", OperandRegister, RegisterClassFPU, 80},
+	{"fpu0", OperandRegister, RegisterClassFPU, 80},
+
+	{"mmxreg", OperandRegister, RegisterClassMMX, 64},
+	{"mmxrm", OperandRegMem, RegisterClassMMX, 0},
+	{"mmxrm64", OperandRegMem, RegisterClassMMX, 64},
+
+	{"xmmreg", OperandRegister, RegisterClassXMM, 128},
+	{"xmm0", OperandRegister, RegisterClassXMM, 128},
+	{"xmmrm", OperandRegMem, RegisterClassXMM, 0},
+	{"xmmrm8", OperandRegMem, RegisterClassXMM, 8},
+	{"xmmrm16", OperandRegMem, RegisterClassXMM, 16},
+	{"xmmrm32", OperandRegMem, RegisterClassXMM, 32},
+	{"xmmrm64", OperandRegMem, RegisterClassXMM, 64},
+	{"xmmrm128", OperandRegMem, RegisterClassXMM, 128},
+	{"ymmreg", OperandRegister, RegisterClassYMM, 256},
+	{"ymmrm256", OperandRegMem, RegisterClassYMM, 256},
+	{"zmmreg", OperandRegister, RegisterClassZMM, 512},
+	{"zmmrm512", OperandRegMem, RegisterClassZMM, 512},
+
+	{"kreg", OperandRegister, RegisterClassMask, 64},
+	{"krm8", OperandRegMem, RegisterClassMask, 8},
+	{"krm16", OperandRegMem, RegisterClassMask, 16},
+	{"krm32", OperandRegMem, RegisterClassMask, 32},
+	{"krm64", OperandRegMem, RegisterClassMask, 64},
+
+	{"bndreg", OperandRegister, RegisterClassBound, 128},
+}
+
+// fixedRegisters maps the operands only accepting a single register to that
+// register.
+var fixedRegisters = map[string]string{
+	"reg_al":  "al",
+	"reg_ax":  "ax",
+	"reg_eax": "eax",
+	"reg_rax": "rax",
+	"reg_cl":  "cl",
+	"reg_cx":  "cx",
+	"reg_ecx": "ecx",
+	"reg_rcx": "rcx",
+	"reg_dx":  "dx",
+	"reg_edx": "edx",
+	"reg_es":  "es",
+	"reg_cs":  "cs",
+	"reg_ss":  "ss",
+	"reg_ds":  "ds",
+	"reg_fs":  "fs",
+	"reg_gs":  "gs",
+	"fpu0":    "st0",
+	"xmm0":    "xmm0",
+}
+
+var immediateRanges = map[string]ImmediateRange{
+	"unity":        ImmediateUnity,
+	"sbyteword":    ImmediateSByte,
+	"sbyteword16":  ImmediateSByte,
+	"sbytedword":   ImmediateSByte,
+	"sbytedword32": ImmediateSByte,
+	"sbytedword64": ImmediateSByte,
+	"sdword":       ImmediateSDword,
+	"udword":       ImmediateUDword,
+}
+
+var vsibClasses = map[byte]RegisterClass{
+	'x': RegisterClassXMM,
+	'y': RegisterClassYMM,
+	'z': RegisterClassZMM,
+}
+
+// OperandFromString parses a single nasm operand, decorators included, eg.
+// "zmmrm512|b32|er".
+func OperandFromString(str string) (Operand, error) {
+	op := Operand{Raw: str}
+
+	if strings.HasSuffix(str, "*") {
+		op.OptionalNDS = true
+		str = str[:len(str)-1]
+	}
+
+	parts := strings.Split(str, "|")
+	base := parts[0]
+
+	var info *operandInfo
+	for i := range operandTab {
+		if operandTab[i].name == base {
+			info = &operandTab[i]
+			break
+		}
+	}
+	if info == nil {
+		return op, fmt.Errorf("no operand with name '%s'", base)
+	}
+
+	op.Kind = info.kind
+	op.Class = info.class
+	op.Size = info.size
+	op.Fixed = fixedRegisters[base]
+	op.NotAccumulator = base == "reg32na"
+	op.Offset = base == "mem_offs"
+	op.Range = immediateRanges[base]
+	if strings.HasSuffix(base, "mem32") || strings.HasSuffix(base, "mem64") {
+		op.VSIB = vsibClasses[base[0]]
+	}
+
+	for _, decorator := range parts[1:] {
+		switch decorator {
+		case "mask":
+			op.Mask = true
+		case "z":
+			op.Zeroing = true
+		case "b32":
+			op.Broadcast = 32
+		case "b64":
+			op.Broadcast = 64
+		case "er":
+			op.Rounding = true
+		case "sae":
+			op.SAE = true
+		case "near":
+			op.Modifier = ModifierNear
+		case "far":
+			op.Modifier = ModifierFar
+		case "short":
+			op.Modifier = ModifierShort
+		case "to":
+			op.Modifier = ModifierTo
+		default:
+			return op, fmt.Errorf("unknown operand decorator '%s' in '%s'",
+				decorator, op.Raw)
+		}
+	}
+
+	return op, nil
+}
+
+// operandError is an operand that couldn't be parsed. offset is the position
+// of operand in the operand field of the instruction.
+type operandError struct {
+	operand string
+	offset  int
+	err     error
+}
+
+// operandsFromStrings parses the operand field of an instruction. The
+// "seg:offset" far pointer syntax yields two operands so the result lines up
+// with the operand letters of the instruction pattern. Operands that can't be
+// parsed are returned as best as possible, with the corresponding errors.
+func operandsFromStrings(strs []string, pattern *Pattern) ([]Operand, []operandError) {
+	if len(strs) == 1 && strs[0] == "void" {
+		return nil, nil
+	}
+
+	var operands []Operand
+	var errs []operandError
+	offset := 0
+	for _, str := range strs {
+		halves := strings.Split(str, ":")
+		for i, half := range halves {
+			op, err := OperandFromString(half)
+			if err != nil {
+				errs = append(errs, operandError{half, offset, err})
+			}
+			op.Colon = i < len(halves)-1
+			operands = append(operands, op)
+			offset += len(half) + 1
+		}
+	}
+
+	// Immediates of branches encoded with a rel/rel8 code are really
+	// displacements from the next instruction.
+	if pattern.hasOpcode("rel") || pattern.hasOpcode("rel8") {
+		for i := range operands {
+			op := &operands[i]
+			if op.Kind == OperandImmediate && op.Modifier != ModifierFar {
+				op.Kind = OperandRelative
+			}
+		}
+	}
+
+	return operands, errs
+}
+
+// String implements the stringer interface for Operand.
+func (o *Operand) String() string {
+	return o.Raw
+}
+
+// IsRegister returns true if the operand can be a register.
+func (o *Operand) IsRegister() bool {
+	return o.Kind == OperandRegister || o.Kind == OperandRegMem
+}
+
+// IsMemory returns true if the operand can be a memory reference.
+func (o *Operand) IsMemory() bool {
+	return o.Kind == OperandMemory || o.Kind == OperandRegMem
+}
+
+// IsImmediate returns true if the operand is an immediate value, relative
+// branch targets included.
+func (o *Operand) IsImmediate() bool {
+	return o.Kind == OperandImmediate || o.Kind == OperandRelative
+}
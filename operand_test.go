@@ -0,0 +1,102 @@
+package x86db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperandFromString(t *testing.T) {
+	tests := []struct {
+		input  string
+		valid  bool
+		golden Operand
+	}{
+		{"zmmreg|mask|z", true, Operand{
+			Kind:    OperandRegister,
+			Class:   RegisterClassZMM,
+			Size:    512,
+			Mask:    true,
+			Zeroing: true,
+		}},
+		{"ymmreg*", true, Operand{
+			Kind:        OperandRegister,
+			Class:       RegisterClassYMM,
+			Size:        256,
+			OptionalNDS: true,
+		}},
+		{"zmmrm512|b32|er", true, Operand{
+			Kind:      OperandRegMem,
+			Class:     RegisterClassZMM,
+			Size:      512,
+			Broadcast: 32,
+			Rounding:  true,
+		}},
+		{"sbytedword", true, Operand{
+			Kind:  OperandImmediate,
+			Range: ImmediateSByte,
+		}},
+		{"reg_eax", true, Operand{
+			Kind:  OperandRegister,
+			Class: RegisterClassGP,
+			Size:  32,
+			Fixed: "eax",
+		}},
+		{"ymem64|mask", true, Operand{
+			Kind: OperandMemory,
+			Size: 64,
+			VSIB: RegisterClassYMM,
+			Mask: true,
+		}},
+		{"mem16|far", true, Operand{
+			Kind:     OperandMemory,
+			Size:     16,
+			Modifier: ModifierFar,
+		}},
+		{"foo", false, Operand{}},
+		{"xmmreg|bar", false, Operand{}},
+	}
+
+	for _, test := range tests {
+		op, err := OperandFromString(test.input)
+		if !test.valid {
+			assert.NotNil(t, err)
+			continue
+		}
+
+		assert.Nil(t, err)
+		test.golden.Raw = test.input
+		assert.Equal(t, test.golden, op)
+	}
+}
+
+func TestOperandsFromStrings(t *testing.T) {
+	tests := []struct {
+		operands string
+		pattern  string
+		kinds    []OperandKind
+	}{
+		{"void", "90", nil},
+		{"imm|near", "i: odf e8 rel", []OperandKind{OperandRelative}},
+		{"imm|far", "i: odf 9a iwd seg", []OperandKind{OperandImmediate}},
+		{"imm16:imm", "ji: o16 9a iw iw",
+			[]OperandKind{OperandImmediate, OperandImmediate}},
+		{"rm32,imm8", "mi: hle o32 83 /2 ib,s",
+			[]OperandKind{OperandRegMem, OperandImmediate}},
+	}
+
+	for _, test := range tests {
+		pattern, err := patternFromString(test.pattern)
+		assert.Nil(t, err)
+
+		args, errs := operandsFromStrings(strings.Split(test.operands, ","), pattern)
+		assert.Empty(t, errs)
+
+		var kinds []OperandKind
+		for _, arg := range args {
+			kinds = append(kinds, arg.Kind)
+		}
+		assert.Equal(t, test.kinds, kinds)
+	}
+}
@@ -0,0 +1,146 @@
+// Package predicate provides composable filters for x86db instructions, to
+// be used with InstructionSlice.Where and friends:
+//
+//	insns := db.Instructions.Where(predicate.And(
+//		predicate.Extension(x86db.ExtensionAVX2),
+//		predicate.Not(predicate.Space(x86db.EncodingEVEX)),
+//	))
+package predicate
+
+import (
+	"regexp"
+
+	"github.com/dlespiau/x86db"
+)
+
+// Predicate returns true for the instructions it selects.
+type Predicate func(insn x86db.Instruction) bool
+
+// True selects all instructions.
+func True(insn x86db.Instruction) bool {
+	return true
+}
+
+// And selects the instructions selected by all of preds.
+func And(preds ...Predicate) Predicate {
+	return func(insn x86db.Instruction) bool {
+		for _, p := range preds {
+			if !p(insn) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or selects the instructions selected by any of preds.
+func Or(preds ...Predicate) Predicate {
+	return func(insn x86db.Instruction) bool {
+		for _, p := range preds {
+			if p(insn) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not selects the instructions not selected by p.
+func Not(p Predicate) Predicate {
+	return func(insn x86db.Instruction) bool {
+		return !p(insn)
+	}
+}
+
+// Extension selects the instructions part of extension. ExtensionBase selects
+// the instructions not part of any extension.
+func Extension(extension x86db.Extension) Predicate {
+	return func(insn x86db.Instruction) bool {
+		return insn.Extensions.Has(extension)
+	}
+}
+
+// Extensions selects the instructions requiring all of extensions.
+func Extensions(extensions x86db.ExtensionSet) Predicate {
+	return func(insn x86db.Instruction) bool {
+		return insn.Extensions.HasAll(extensions)
+	}
+}
+
+// CPULevel selects the instructions available on processors of the given
+// level. Instructions without a CPU level aren't selected.
+func CPULevel(level x86db.CPULevel) Predicate {
+	return func(insn x86db.Instruction) bool {
+		return insn.CPULevel != x86db.CPULevelNone && insn.CPULevel <= level
+	}
+}
+
+// Mode selects the instructions that can be assembled in all of modes.
+func Mode(modes x86db.Modes) Predicate {
+	return func(insn x86db.Instruction) bool {
+		return insn.Modes.Has(modes)
+	}
+}
+
+// Attr selects the instructions with all the attributes of attr set.
+func Attr(attr x86db.Attr) Predicate {
+	return func(insn x86db.Instruction) bool {
+		return insn.Attr.Has(attr)
+	}
+}
+
+// Group selects the instructions listed in group.
+func Group(group x86db.Group) Predicate {
+	return func(insn x86db.Instruction) bool {
+		return insn.Group.Ordinal == group.Ordinal
+	}
+}
+
+// Space selects the instructions using the given encoding space.
+func Space(space x86db.EncodingSpace) Predicate {
+	return func(insn x86db.Instruction) bool {
+		return insn.Encoding.Space == space
+	}
+}
+
+// Mnemonic selects the instructions whose mnemonic matches re.
+func Mnemonic(re *regexp.Regexp) Predicate {
+	return func(insn x86db.Instruction) bool {
+		return re.MatchString(insn.Name)
+	}
+}
+
+// anyOperand selects the instructions with at least one operand for which
+// fn returns true.
+func anyOperand(fn func(op *x86db.Operand) bool) Predicate {
+	return func(insn x86db.Instruction) bool {
+		for i := range insn.Args {
+			if fn(&insn.Args[i]) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// OperandKind selects the instructions with an operand of the given kind.
+func OperandKind(kind x86db.OperandKind) Predicate {
+	return anyOperand(func(op *x86db.Operand) bool {
+		return op.Kind == kind
+	})
+}
+
+// OperandClass selects the instructions with a register operand of the given
+// class, register/memory operands included.
+func OperandClass(class x86db.RegisterClass) Predicate {
+	return anyOperand(func(op *x86db.Operand) bool {
+		return op.Class == class
+	})
+}
+
+// OperandSize selects the instructions with an operand of size bits.
+func OperandSize(size int) Predicate {
+	return anyOperand(func(op *x86db.Operand) bool {
+		return op.Size == size
+	})
+}
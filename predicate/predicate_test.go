@@ -0,0 +1,81 @@
+package predicate
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/dlespiau/x86db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombinators(t *testing.T) {
+	insn := x86db.Instruction{Name: "ADDPS"}
+	yes := Mnemonic(regexp.MustCompile("^ADD"))
+	no := Not(yes)
+
+	tests := []struct {
+		p      Predicate
+		golden bool
+	}{
+		{True, true},
+		{yes, true},
+		{no, false},
+		{And(), true},
+		{And(yes, yes), true},
+		{And(yes, no), false},
+		{Or(), false},
+		{Or(no, yes), true},
+		{Or(no, no), false},
+		{Not(And(yes, no)), true},
+	}
+
+	for i, test := range tests {
+		assert.Equal(t, test.golden, test.p(insn), "test %d", i)
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	db := x86db.NewDB()
+	assert.Nil(t, db.Open())
+
+	// count is the number of forms in insns.dat, in and out are mnemonics
+	// with and without forms selected.
+	tests := []struct {
+		name  string
+		p     Predicate
+		count int
+		in    []string
+		out   []string
+	}{
+		{"extension", Extension(x86db.ExtensionAVX2), 186,
+			[]string{"VPERMD"}, []string{"VPERMILPS", "ADD"}},
+		{"cpu level", CPULevel(x86db.CPULevel486), 981,
+			[]string{"BSWAP", "AAA", "ADD"}, []string{"CPUID", "RDTSC"}},
+		{"mode", Mode(x86db.Mode64), 4898,
+			[]string{"SWAPGS", "ADD"}, []string{"AAA"}},
+		{"attr", Attr(x86db.AttrPRIV), 33,
+			[]string{"HLT", "LGDT", "MOV"}, []string{"ADD"}},
+		{"space", Space(x86db.EncodingXOP), 103,
+			[]string{"VPCMOV"}, []string{"VFMADDPD", "VADDPS"}},
+		{"operand kind", OperandKind(x86db.OperandRelative), 61,
+			[]string{"JMP", "LOOP"}, []string{"MOV"}},
+		{"operand size", OperandSize(512), 472,
+			[]string{"VADDPS", "VPERMD"}, []string{"ADDPS"}},
+	}
+
+	for _, test := range tests {
+		selected := db.Instructions.Where(test.p)
+		assert.Equal(t, test.count, len(selected), test.name)
+
+		names := make(map[string]bool)
+		for _, insn := range selected {
+			names[insn.Name] = true
+		}
+		for _, name := range test.in {
+			assert.True(t, names[name], "%s: %s", test.name, name)
+		}
+		for _, name := range test.out {
+			assert.False(t, names[name], "%s: %s", test.name, name)
+		}
+	}
+}
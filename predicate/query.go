@@ -0,0 +1,395 @@
+package predicate
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/dlespiau/x86db"
+)
+
+// SyntaxError is an error in a query. Pos is the byte offset of the
+// offending token.
+type SyntaxError struct {
+	Query string
+	Pos   int
+	Token string
+	Msg   string
+}
+
+// Error implements the error interface for SyntaxError. The message shows
+// the query with a caret under the offending token.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("query:%d: %s\n  %s\n  %s^", e.Pos+1, e.Msg, e.Query,
+		strings.Repeat(" ", e.Pos))
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenTerm
+)
+
+type token struct {
+	kind tokenKind
+	pos  int
+	text string
+	// field and value are the two halves of a term.
+	field, value string
+}
+
+// lexer splits a query into tokens.
+type lexer struct {
+	query string
+	pos   int
+}
+
+func (l *lexer) errorf(pos int, text string, format string, a ...interface{}) error {
+	return &SyntaxError{l.query, pos, text, fmt.Sprintf(format, a...)}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.query) && isSpace(l.query[l.pos]) {
+		l.pos++
+	}
+
+	start := l.pos
+	if l.pos >= len(l.query) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	switch l.query[l.pos] {
+	case '(':
+		l.pos++
+		return token{kind: tokenLParen, pos: start, text: "("}, nil
+	case ')':
+		l.pos++
+		return token{kind: tokenRParen, pos: start, text: ")"}, nil
+	}
+
+	// Words: keywords and field:value terms. Values may be quoted.
+	for l.pos < len(l.query) {
+		c := l.query[l.pos]
+		if isSpace(c) || c == '(' || c == ')' {
+			break
+		}
+		if c == '"' {
+			end := strings.IndexByte(l.query[l.pos+1:], '"')
+			if end < 0 {
+				return token{}, l.errorf(l.pos, l.query[l.pos:],
+					"unterminated quoted value")
+			}
+			l.pos += end + 2
+			continue
+		}
+		l.pos++
+	}
+	text := l.query[start:l.pos]
+
+	switch strings.ToLower(text) {
+	case "and":
+		return token{kind: tokenAnd, pos: start, text: text}, nil
+	case "or":
+		return token{kind: tokenOr, pos: start, text: text}, nil
+	case "not":
+		return token{kind: tokenNot, pos: start, text: text}, nil
+	}
+
+	sep := strings.IndexByte(text, ':')
+	if sep <= 0 || sep == len(text)-1 {
+		return token{}, l.errorf(start, text, "expected field:value, got '%s'", text)
+	}
+
+	return token{
+		kind:  tokenTerm,
+		pos:   start,
+		text:  text,
+		field: strings.ToLower(text[:sep]),
+		value: strings.Trim(text[sep+1:], `"`),
+	}, nil
+}
+
+// parser is a recursive descent parser of queries:
+//
+//	or      = and { "or" and }
+//	and     = unary { "and" unary }
+//	unary   = "not" unary | primary
+//	primary = "(" or ")" | term
+type parser struct {
+	lexer lexer
+	tok   token
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) errorf(format string, a ...interface{}) error {
+	text := p.tok.text
+	if p.tok.kind == tokenEOF {
+		text = "end of query"
+	}
+	return p.lexer.errorf(p.tok.pos, text, format, a...)
+}
+
+func (p *parser) parseOr() (Predicate, error) {
+	preds := []Predicate{}
+	for {
+		pred, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+
+		if p.tok.kind != tokenOr {
+			break
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(preds) == 1 {
+		return preds[0], nil
+	}
+	return Or(preds...), nil
+}
+
+func (p *parser) parseAnd() (Predicate, error) {
+	preds := []Predicate{}
+	for {
+		pred, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+
+		if p.tok.kind != tokenAnd {
+			break
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(preds) == 1 {
+		return preds[0], nil
+	}
+	return And(preds...), nil
+}
+
+func (p *parser) parseUnary() (Predicate, error) {
+	if p.tok.kind != tokenNot {
+		return p.parsePrimary()
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	pred, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return Not(pred), nil
+}
+
+func (p *parser) parsePrimary() (Predicate, error) {
+	switch p.tok.kind {
+	case tokenLParen:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		pred, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenRParen {
+			return nil, p.errorf("expected ')'")
+		}
+		return pred, p.advance()
+	case tokenTerm:
+		pred, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		return pred, p.advance()
+	}
+
+	return nil, p.errorf("expected a field:value term")
+}
+
+type queryField struct {
+	name  string
+	help  string
+	parse func(value string) (Predicate, error)
+}
+
+// queryFields lists the fields of the query language.
+var queryFields = []queryField{
+	{"ext", "extensions, comma separated: all required", func(value string) (Predicate, error) {
+		exts, err := x86db.ExtensionSetFromString(strings.ToUpper(value))
+		if err != nil {
+			return nil, err
+		}
+		return Extensions(exts), nil
+	}},
+	{"level", "CPU level the instruction is available at", func(value string) (Predicate, error) {
+		level, err := x86db.CPULevelFromString(strings.ToUpper(value))
+		if err != nil {
+			return nil, err
+		}
+		return CPULevel(level), nil
+	}},
+	{"mode", "execution modes, comma separated: 16, 32 or 64", func(value string) (Predicate, error) {
+		modes, err := x86db.ModesFromString(value)
+		if err != nil {
+			return nil, err
+		}
+		return Mode(modes), nil
+	}},
+	{"attr", "attribute flags, comma separated: all required", func(value string) (Predicate, error) {
+		var attr x86db.Attr
+		for _, name := range strings.Split(strings.ToUpper(value), ",") {
+			a, err := x86db.AttrFromString(name)
+			if err != nil {
+				return nil, err
+			}
+			attr |= a
+		}
+		return Attr(attr), nil
+	}},
+	{"name", "mnemonic glob pattern", func(value string) (Predicate, error) {
+		pattern := strings.ToUpper(value)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+		return func(insn x86db.Instruction) bool {
+			matched, _ := path.Match(pattern, strings.ToUpper(insn.Name))
+			return matched
+		}, nil
+	}},
+	{"operand", "operand glob pattern, eg. zmm*", func(value string) (Predicate, error) {
+		pattern := strings.ToLower(value)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+		return func(insn x86db.Instruction) bool {
+			for _, op := range insn.Operands {
+				if matched, _ := path.Match(pattern, op); matched {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}},
+	{"kind", "operand kind: reg, mem, rm, imm or rel", func(value string) (Predicate, error) {
+		for k := x86db.OperandNone; k <= x86db.OperandRelative; k++ {
+			if k.String() == strings.ToLower(value) {
+				return OperandKind(k), nil
+			}
+		}
+		return nil, fmt.Errorf("no OperandKind with name '%s'", value)
+	}},
+	{"class", "register class, eg. gp, xmm, mask", func(value string) (Predicate, error) {
+		for c := x86db.RegisterClassNone; c <= x86db.RegisterClassBound; c++ {
+			if c.String() == strings.ToLower(value) {
+				return OperandClass(c), nil
+			}
+		}
+		return nil, fmt.Errorf("no RegisterClass with name '%s'", value)
+	}},
+	{"size", "operand size in bits", func(value string) (Predicate, error) {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size '%s'", value)
+		}
+		return OperandSize(size), nil
+	}},
+	{"space", "encoding space: legacy, vex, xop or evex", func(value string) (Predicate, error) {
+		for s := x86db.EncodingLegacy; s <= x86db.EncodingEVEX; s++ {
+			if s.String() == strings.ToLower(value) {
+				return Space(s), nil
+			}
+		}
+		return nil, fmt.Errorf("no EncodingSpace with name '%s'", value)
+	}},
+	{"group", "group title prefix, case insensitive", func(value string) (Predicate, error) {
+		prefix := strings.ToLower(value)
+		return func(insn x86db.Instruction) bool {
+			return strings.HasPrefix(strings.ToLower(insn.Group.Title), prefix)
+		}, nil
+	}},
+}
+
+func (p *parser) parseTerm() (Predicate, error) {
+	for _, field := range queryFields {
+		if field.name != p.tok.field {
+			continue
+		}
+		pred, err := field.parse(p.tok.value)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		return pred, nil
+	}
+
+	return nil, p.errorf("unknown field '%s'", p.tok.field)
+}
+
+// Parse parses a query into a Predicate. A query is a boolean expression of
+// field:value terms, eg.
+//
+//	ext:AVX512BW and mode:64 and operand:zmm* and not attr:UNDOC
+//
+// Terms can be combined with and, or, not and parentheses. not binds tighter
+// than and, which binds tighter than or. Values with spaces can be quoted, eg.
+// group:"Intel AES". See QueryFields for the list of fields.
+//
+// Errors are of type *SyntaxError.
+func Parse(query string) (Predicate, error) {
+	p := &parser{lexer: lexer{query: query}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	pred, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf("unexpected '%s'", p.tok.text)
+	}
+
+	return pred, nil
+}
+
+// FieldInfo describes a field of the query language.
+type FieldInfo struct {
+	Name string
+	Help string
+}
+
+// QueryFields returns the fields of the query language.
+func QueryFields() []FieldInfo {
+	var fields []FieldInfo
+	for _, field := range queryFields {
+		fields = append(fields, FieldInfo{field.name, field.help})
+	}
+	return fields
+}
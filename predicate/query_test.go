@@ -0,0 +1,90 @@
+package predicate
+
+import (
+	"testing"
+
+	"github.com/dlespiau/x86db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	addps := x86db.Instruction{
+		Name:       "ADDPS",
+		Operands:   []string{"xmmreg", "xmmrm128"},
+		Args:       []x86db.Operand{{Kind: x86db.OperandRegister, Class: x86db.RegisterClassXMM, Size: 128}},
+		Extensions: x86db.NewExtensionSet(x86db.ExtensionSSE),
+		CPULevel:   x86db.CPULevelKATMAI,
+		Modes:      x86db.ModesAll,
+	}
+	vaddps := x86db.Instruction{
+		Name:       "VADDPS",
+		Operands:   []string{"zmmreg|mask|z", "zmmreg*", "zmmrm512|b32|er"},
+		Extensions: x86db.NewExtensionSet(x86db.ExtensionAVX512),
+		CPULevel:   x86db.CPULevelFUTURE,
+		Modes:      x86db.ModesAll,
+		Encoding:   x86db.Encoding{Space: x86db.EncodingEVEX},
+	}
+	aaa := x86db.Instruction{
+		Name:     "AAA",
+		Operands: []string{"void"},
+		Attr:     x86db.AttrNOLONG,
+		Modes:    x86db.Mode16 | x86db.Mode32,
+		Group:    x86db.Group{Title: "Conventional instructions", Ordinal: 2},
+	}
+
+	tests := []struct {
+		query  string
+		golden []bool
+	}{
+		{"ext:SSE", []bool{true, false, false}},
+		{"ext:avx512 and mode:64 and operand:zmm* and not attr:UNDOC",
+			[]bool{false, true, false}},
+		{"name:*ADD* and not space:evex", []bool{true, false, false}},
+		{"mode:64 or attr:NOLONG", []bool{true, true, true}},
+		{"not (name:AAA or level:KATMAI)", []bool{false, true, false}},
+		{"NOT name:AAA AND NOT name:VADDPS", []bool{true, false, false}},
+		{"class:xmm", []bool{true, false, false}},
+		{"size:128 and kind:reg", []bool{true, false, false}},
+		{`group:"conventional instr"`, []bool{false, false, true}},
+	}
+
+	insns := []x86db.Instruction{addps, vaddps, aaa}
+	for _, test := range tests {
+		p, err := Parse(test.query)
+		if !assert.Nil(t, err, test.query) {
+			continue
+		}
+		for i, insn := range insns {
+			assert.Equal(t, test.golden[i], p(insn), "%s: %s", test.query, insn.Name)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		token string
+	}{
+		{"ext:AVX2 and foo:bar", 13, "foo:bar"},
+		{"ext:AVX2 and", 12, "end of query"},
+		{"ext:AVX2 or ext:NOPE", 12, "ext:NOPE"},
+		{"(ext:AVX2 or mode:64", 20, "end of query"},
+		{"ext:AVX2 mode:64", 9, "mode:64"},
+		{"ext:AVX2 and AVX512", 13, "AVX512"},
+		{"not )", 4, ")"},
+		{`group:"Intel`, 6, `"Intel`},
+		{"mode:128", 0, "mode:128"},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.query)
+		serr, ok := err.(*SyntaxError)
+		if !assert.True(t, ok, test.query) {
+			continue
+		}
+		assert.Equal(t, test.pos, serr.Pos, test.query)
+		assert.Equal(t, test.token, serr.Token, test.query)
+		assert.NotEqual(t, "", serr.Msg, test.query)
+	}
+}
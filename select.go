@@ -0,0 +1,220 @@
+package x86db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SelectOptions controls the forms considered when selecting an encoding.
+type SelectOptions struct {
+	// Optimize allows the forms only used by optimizing assemblers: the
+	// OPT forms and the short jumps taken when the target is in range.
+	Optimize bool
+}
+
+// Candidate is an instruction form able to encode a list of operands.
+type Candidate struct {
+	Instruction *Instruction
+	// Length is the length in bytes of the encoding. Memory operands are
+	// counted as a register indirect address without displacement, the
+	// address bytes being the same for all forms.
+	Length int
+}
+
+// immediateFits returns true if the value of spec can be stored in an
+// immediate of type t.
+func immediateFits(t ImmediateType, spec *OperandSpec) bool {
+	if !spec.HasValue {
+		return true
+	}
+
+	v := spec.Value
+	switch t {
+	case ImmSByte, ImmRel8:
+		return v >= -128 && v < 128
+	case ImmByte, ImmUByte:
+		return fits(v, 8)
+	case ImmWord:
+		return fits(v, 16)
+	case ImmDword, ImmWordDword:
+		return fits(v, 32)
+	case ImmSDword, ImmRel:
+		return v >= -1<<31 && v < 1<<31
+	}
+	return true
+}
+
+// encodingOperandSize returns the operand size of e in 64-bit mode.
+func encodingOperandSize(e *Encoding) int {
+	switch e.OperandSize {
+	case Size16:
+		return 16
+	case Size64, Size64NoW, SizeDefault:
+		return 64
+	}
+	return 32
+}
+
+// extended returns true if the register spec needs a REX, VEX or EVEX bit.
+func (s *OperandSpec) extended() bool {
+	return s.Kind == OperandRegister && s.Register >= 8
+}
+
+// needsREX returns true if the legacy encoding of insn with the aligned
+// operands needs a REX prefix.
+func needsREX(insn *Instruction, specs []OperandSpec) bool {
+	e := &insn.Encoding
+	if e.OperandSize == Size64 && !e.Flags.Has(EncodingNoRexW) {
+		return true
+	}
+	for i := range specs {
+		if specs[i].extended() || rexByteRegisters[strings.ToLower(specs[i].Raw)] {
+			return true
+		}
+	}
+	return false
+}
+
+// vexLength returns the length of the VEX prefix: the 2-byte form can only be
+// used when X, B, W and the map don't need to be encoded.
+func vexLength(insn *Instruction, specs []OperandSpec) int {
+	e := &insn.Encoding
+	if e.Space == EncodingXOP || e.Map != Map0F || e.W == W1 {
+		return 3
+	}
+	if e.ModRM != nil && e.ModRM.RM >= 0 && specs[e.ModRM.RM].extended() {
+		return 3
+	}
+	return 2
+}
+
+// encodingLength returns the length of the encoding of insn with the aligned
+// operands, ok is false if an operand value can't be encoded.
+func encodingLength(insn *Instruction, specs []OperandSpec) (n int, ok bool) {
+	e := &insn.Encoding
+
+	switch e.Space {
+	case EncodingLegacy:
+		if e.Flags.Has(EncodingWait) {
+			n++
+		}
+		if e.OperandSize == Size16 {
+			n++
+		}
+		if e.AddressSize == Size32 {
+			n++
+		}
+		if e.Prefix != 0 {
+			n++
+		}
+		if e.Flags.Has(EncodingMustRep) || e.Flags.Has(EncodingMustRepNE) {
+			n++
+		}
+		if needsREX(insn, specs) {
+			n++
+		}
+		switch e.Map {
+		case Map0F:
+			n++
+		case Map0F38, Map0F3A:
+			n += 2
+		}
+	case EncodingVEX, EncodingXOP:
+		n += vexLength(insn, specs)
+	case EncodingEVEX:
+		n += 4
+	}
+
+	n += len(e.Opcode)
+	if e.ModRM != nil {
+		n++
+		if e.VSIB != RegisterClassNone || e.ModRM.Index >= 0 {
+			n++
+		}
+	}
+	n += len(e.Suffix)
+
+	operandSize := encodingOperandSize(e)
+	for _, imm := range e.Immediates {
+		if imm.Operand >= 0 && !immediateFits(imm.Type, &specs[imm.Operand]) {
+			return 0, false
+		}
+		n += imm.Type.Length(operandSize, 64)
+	}
+
+	return n, true
+}
+
+// Candidates returns the 64-bit mode forms of mnemonic able to encode the
+// given operands, shortest encoding first. Forms of equal length are ordered
+// as returned by MatchOperands, ND forms last: they are alternate spellings
+// of encodings also listed without ND.
+//
+// Pseudo-mnemonics are resolved to their canonical instruction, with the
+// implied imm8 operand.
+func (db *DB) Candidates(mnemonic string, options SelectOptions, specs ...OperandSpec) []Candidate {
+	if canonical, imm8, ok := db.Canonical(strings.ToUpper(mnemonic)); ok {
+		mnemonic = canonical
+		specs = append(append([]OperandSpec(nil), specs...), OperandSpec{
+			Operand:  Operand{Raw: fmt.Sprint(imm8), Kind: OperandImmediate},
+			Value:    int64(imm8),
+			HasValue: true,
+		})
+	}
+
+	var candidates []Candidate
+	matches := db.MatchOperands(mnemonic, specs...)
+	for i := range matches {
+		insn := &matches[i]
+		if !insn.Modes.Has(Mode64) {
+			continue
+		}
+
+		e := &insn.Encoding
+		optimizing := insn.OpSize&OpSizeOPT != 0 ||
+			e.Flags.Has(EncodingJmp8) || e.Flags.Has(EncodingJcc8)
+		if optimizing && !options.Optimize {
+			continue
+		}
+
+		aligned, _ := insn.alignOperands(specs)
+		// The short jumps of optimizing assemblers need a target known to
+		// be in range.
+		if (e.Flags.Has(EncodingJmp8) || e.Flags.Has(EncodingJcc8)) &&
+			!aligned[0].HasValue {
+			continue
+		}
+
+		length, ok := encodingLength(insn, aligned)
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, Candidate{insn, length})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Length != b.Length {
+			return a.Length < b.Length
+		}
+		return !a.Instruction.Attr.Has(AttrND) && b.Instruction.Attr.Has(AttrND)
+	})
+
+	return candidates
+}
+
+// SelectShortest returns the 64-bit mode form of mnemonic giving the shortest
+// encoding of the operands, see Candidates.
+func (db *DB) SelectShortest(mnemonic string, options SelectOptions, specs ...OperandSpec) (Candidate, error) {
+	candidates := db.Candidates(mnemonic, options, specs...)
+	if len(candidates) == 0 {
+		raw := make([]string, len(specs))
+		for i := range specs {
+			raw[i] = specs[i].Raw
+		}
+		return Candidate{}, fmt.Errorf("no form of %s accepts '%s'", mnemonic,
+			strings.Join(raw, ","))
+	}
+	return candidates[0], nil
+}
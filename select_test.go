@@ -0,0 +1,66 @@
+package x86db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectShortest(t *testing.T) {
+	db := NewDBWithOptions(bundledFile, LoadOptions{ExpandConditions: true})
+	assert.Nil(t, db.Open())
+
+	tests := []struct {
+		mnemonic string
+		optimize bool
+		specs    []string
+		valid    bool
+		operands string
+		length   int
+	}{
+		{"SBB", false, []string{"eax", "1"}, true, "rm32,imm8", 3},
+		{"SBB", false, []string{"eax", "1000"}, true, "reg_eax,imm", 5},
+		{"MOV", false, []string{"rax", "1"}, true, "rm64,imm32", 7},
+		{"MOV", true, []string{"rax", "1"}, true, "reg64,udword", 5},
+		{"MOV", true, []string{"r10", "-1"}, true, "rm64,imm32", 7},
+		{"MOV", false, []string{"rax", "0x100000000"}, true, "reg64,imm", 10},
+		{"SHL", false, []string{"eax", "1"}, true, "rm32,unity", 2},
+		{"JMP", false, []string{"16"}, true, "imm64", 5},
+		{"JMP", true, []string{"16"}, true, "imm", 2},
+		{"JMP", true, []string{"1000"}, true, "imm64", 5},
+		{"JNE", true, []string{"-2"}, true, "imm", 2},
+		{"CMPEQPS", false, []string{"xmm1", "xmm2"}, true, "xmmreg,xmmreg,imm", 4},
+		{"VADDPS", false, []string{"xmm1", "xmm2", "xmm3"}, true,
+			"xmmreg,xmmreg*,xmmrm128", 4},
+		{"VADDPS", false, []string{"xmm1", "xmm2", "xmm9"}, true,
+			"xmmreg,xmmreg*,xmmrm128", 5},
+		{"VADDPS", false, []string{"xmm1", "xmm2", "xmm20"}, true,
+			"xmmreg|mask|z,xmmreg*,xmmrm128|b32", 6},
+		{"ADD", false, []string{"spl", "1"}, true, "rm8,imm", 4},
+		{"ADD", false, []string{"eax"}, false, "", 0},
+		// ah can't be encoded with the REX prefix r8b needs.
+		{"ADD", false, []string{"ah", "cl"}, true, "reg8,reg8", 2},
+		{"ADD", false, []string{"ah", "r8b"}, false, "", 0},
+	}
+
+	for _, test := range tests {
+		var specs []OperandSpec
+		for _, str := range test.specs {
+			spec, err := OperandSpecFromString(str)
+			assert.Nil(t, err)
+			specs = append(specs, spec)
+		}
+
+		c, err := db.SelectShortest(test.mnemonic,
+			SelectOptions{Optimize: test.optimize}, specs...)
+		if !test.valid {
+			assert.NotNil(t, err)
+			continue
+		}
+		assert.Nil(t, err, "%s %v", test.mnemonic, test.specs)
+		assert.Equal(t, test.operands, strings.Join(c.Instruction.Operands, ","),
+			"%s %v", test.mnemonic, test.specs)
+		assert.Equal(t, test.length, c.Length, "%s %v", test.mnemonic, test.specs)
+	}
+}